@@ -0,0 +1,343 @@
+package dbwrap
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// These constants enumerate outcomes of audited statements.
+const (
+	AuditAutocommit   = "autocommit"
+	AuditCommitted    = "committed"
+	AuditCommitFailed = "commit_failed"
+	AuditRolledBack   = "rolled_back"
+)
+
+// AuditRecord is a line of audit log.
+type AuditRecord struct {
+	Seq          int64           `json:"seq"`
+	Time         time.Time       `json:"time"`
+	Operation    Operation       `json:"operation"`
+	Statement    string          `json:"statement"`
+	Args         []CassetteValue `json:"args,omitempty"`
+	RowsAffected *int64          `json:"rowsAffected,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	Caller       string          `json:"caller,omitempty"`
+	Actor        string          `json:"actor,omitempty"`
+
+	// Tx identifies transaction of statement, zero for autocommit.
+	Tx      int64  `json:"tx,omitempty"`
+	Outcome string `json:"outcome"`
+
+	// Prev is a hash of previous record, Hash is a SHA-256 of this record without Hash.
+	Prev string `json:"prev"`
+	Hash string `json:"hash,omitempty"`
+}
+
+// AuditConfig controls AuditLog.
+type AuditConfig struct {
+	// Redact returns arguments to log, for example Redactor.Redact, arguments are not logged if Redact is nil.
+	Redact func(statement string, args []driver.NamedValue) []driver.NamedValue
+
+	// KeepRolledBack writes rolled back statements with AuditRolledBack outcome instead of dropping them.
+	KeepRolledBack bool
+
+	// PrevHash continues hash chain of existing log, see VerifyAuditLog.
+	PrevHash string
+
+	// CallerSkip lists packages to skip when detecting caller, see CallerCtx.
+	CallerSkip []string
+}
+
+type auditActorCtxKey struct{}
+
+// WithAuditActor adds actor to context for audit log.
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorCtxKey{}, actor)
+}
+
+// AuditLog writes a tamper-evident JSON Lines log of data-modifying statements.
+//
+// Every record is hash-chained to the previous one. Statements in transaction are buffered
+// until commit, and are dropped or marked on rollback. Statements of transaction that is abandoned,
+// because connection is closed or reset, are dropped.
+type AuditLog struct {
+	config AuditConfig
+	w      io.Writer
+
+	mu      sync.Mutex
+	seq     int64
+	prev    string
+	err     error
+	pending map[*txState][]AuditRecord
+}
+
+// NewAuditLog creates AuditLog that appends records to w.
+func NewAuditLog(w io.Writer, config AuditConfig) *AuditLog {
+	return &AuditLog{
+		config:  config,
+		w:       w,
+		prev:    config.PrevHash,
+		pending: make(map[*txState][]AuditRecord),
+	}
+}
+
+// WithAuditLog adds audit log to a db wrapper.
+func WithAuditLog(a *AuditLog) Option {
+	return withHook(hook{
+		query:   a.query,
+		exec:    a.exec,
+		call:    a.call,
+		abandon: a.abandon,
+	})
+}
+
+// Err returns the first error of writing log.
+func (a *AuditLog) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.err
+}
+
+func (a *AuditLog) record(ctx context.Context, operation Operation, statement string, args []driver.NamedValue) AuditRecord {
+	r := AuditRecord{
+		Time:      time.Now().UTC(),
+		Operation: operation,
+		Statement: statement,
+		Caller:    CallerCtx(ctx, a.config.CallerSkip...),
+		Outcome:   AuditAutocommit,
+	}
+
+	r.Actor, _ = ctx.Value(auditActorCtxKey{}).(string)
+
+	if a.config.Redact != nil {
+		r.Args = cassetteValues(a.config.Redact(statement, args))
+	}
+
+	return r
+}
+
+// add writes record or buffers it until the end of transaction.
+func (a *AuditLog) add(ctx context.Context, r AuditRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if tx := txFromContext(ctx); tx != nil {
+		r.Tx = tx.id
+		a.pending[tx] = append(a.pending[tx], r)
+
+		return
+	}
+
+	a.write(r)
+}
+
+// write appends hash-chained record, mutex must be locked.
+func (a *AuditLog) write(r AuditRecord) {
+	if a.err != nil {
+		return
+	}
+
+	a.seq++
+	r.Seq = a.seq
+	r.Prev = a.prev
+	r.Hash = ""
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		a.err = err
+
+		return
+	}
+
+	r.Hash = auditHash(b)
+
+	if b, err = json.Marshal(r); err != nil {
+		a.err = err
+
+		return
+	}
+
+	if _, err := a.w.Write(append(b, '\n')); err != nil {
+		a.err = err
+
+		return
+	}
+
+	a.prev = r.Hash
+}
+
+func auditHash(b []byte) string {
+	h := sha256.Sum256(b)
+
+	return hex.EncodeToString(h[:])
+}
+
+func (a *AuditLog) exec(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+	next func(ctx context.Context) (driver.Result, error),
+) (driver.Result, error) {
+	if parseStatement(ctx, statement).statement().ReadOnly {
+		return next(ctx)
+	}
+
+	r := a.record(ctx, operation, statement, args)
+
+	res, err := next(ctx)
+
+	// Statement is executed again with prepared statement.
+	if err == driver.ErrSkip {
+		return res, err
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+	} else if cnt, err := res.RowsAffected(); err == nil {
+		r.RowsAffected = &cnt
+	}
+
+	a.add(ctx, r)
+
+	return res, err
+}
+
+func (a *AuditLog) query(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+	next func(ctx context.Context) (driver.Rows, error),
+) (driver.Rows, error) {
+	if parseStatement(ctx, statement).statement().ReadOnly {
+		return next(ctx)
+	}
+
+	// Data-modifying statement with result rows, for example INSERT ... RETURNING.
+	r := a.record(ctx, operation, statement, args)
+
+	rows, err := next(ctx)
+
+	// Statement is executed again with prepared statement.
+	if err == driver.ErrSkip {
+		return rows, err
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	a.add(ctx, r)
+
+	return rows, err
+}
+
+func (a *AuditLog) call(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	next func(ctx context.Context) error,
+) error {
+	err := next(ctx)
+
+	if operation != Commit && operation != Rollback {
+		return err
+	}
+
+	tx := txFromContext(ctx)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	records := a.pending[tx]
+	delete(a.pending, tx)
+
+	outcome := AuditCommitted
+
+	switch {
+	case operation == Rollback:
+		if !a.config.KeepRolledBack {
+			return err
+		}
+
+		outcome = AuditRolledBack
+	case err != nil:
+		outcome = AuditCommitFailed
+	}
+
+	for _, r := range records {
+		r.Outcome = outcome
+		a.write(r)
+	}
+
+	return err
+}
+
+// abandon drops buffered statements of transaction that is neither committed nor rolled back.
+func (a *AuditLog) abandon(tx *txState) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.pending, tx)
+}
+
+// VerifyAuditLog checks hash chain of audit log and returns hash of the last record.
+//
+// Hash chain must start with prevHash, that is AuditConfig.PrevHash of the log,
+// so that records removed from the head of the log are detected.
+func VerifyAuditLog(r io.Reader, prevHash string) (string, error) {
+	var (
+		s    = bufio.NewScanner(r)
+		prev = prevHash
+		line int
+	)
+
+	s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for s.Scan() {
+		line++
+
+		var rec AuditRecord
+
+		if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+			return "", fmt.Errorf("failed to decode audit line %d: %v", line, err)
+		}
+
+		if rec.Prev != prev {
+			return "", fmt.Errorf("invalid audit line %d: %v", line, errAuditChain)
+		}
+
+		hash := rec.Hash
+		rec.Hash = ""
+
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode audit line %d: %v", line, err)
+		}
+
+		if auditHash(b) != hash {
+			return "", fmt.Errorf("invalid audit line %d: %v", line, errAuditHash)
+		}
+
+		prev = hash
+	}
+
+	return prev, s.Err()
+}
+
+var (
+	errAuditChain = errors.New("audit record is not chained to previous record")
+	errAuditHash  = errors.New("audit record hash mismatch")
+)
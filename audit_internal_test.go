@@ -0,0 +1,69 @@
+package dbwrap
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type txConn struct {
+	driver.Conn
+}
+
+func (c txConn) Begin() (driver.Tx, error) {
+	return txConn{}, nil
+}
+
+func (c txConn) ExecContext(_ context.Context, _ string, _ []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (c txConn) Commit() error {
+	return nil
+}
+
+func (c txConn) Rollback() error {
+	return nil
+}
+
+func (c txConn) ResetSession(_ context.Context) error {
+	return nil
+}
+
+func (c txConn) Close() error {
+	return nil
+}
+
+func TestAuditLog_abandon(t *testing.T) {
+	var (
+		ctx = context.Background()
+		buf bytes.Buffer
+	)
+
+	audit := NewAuditLog(&buf, AuditConfig{KeepRolledBack: true})
+
+	for _, end := range []func(c *wConn) error{
+		func(c *wConn) error { return c.Close() },
+		func(c *wConn) error { return c.ResetSession(ctx) },
+	} {
+		o, _ := prepareOptions([]Option{WithAuditLog(audit)})
+		c := newConn(txConn{}, o)
+
+		_, err := c.BeginTx(ctx, driver.TxOptions{})
+		require.NoError(t, err)
+
+		_, err = c.ExecContext(ctx, "DELETE FROM t", nil)
+		require.NoError(t, err)
+		assert.Len(t, audit.pending, 1)
+
+		require.NoError(t, end(c))
+		assert.Empty(t, audit.pending)
+		assert.False(t, InTx(c.txContext(ctx)))
+	}
+
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,211 @@
+package dbwrap_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAuditLog(t *testing.T) {
+	var (
+		ctx = dbwrap.WithAuditActor(context.Background(), "alice")
+		f   = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		buf bytes.Buffer
+	)
+
+	audit := dbwrap.NewAuditLog(&buf, dbwrap.AuditConfig{
+		Redact: dbwrap.NewRedactor(dbwrap.RedactConfig{Mask: "***"}).Redact,
+	})
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithAuditLog(audit)))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err := db.ExecContext(ctx, "UPDATE users SET password = ? WHERE id = ?", "secret", 1)
+	require.NoError(t, err)
+
+	rows, err := db.QueryContext(ctx, "SELECT * FROM users")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM users WHERE id = ?", 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"), "transaction must be buffered")
+
+	require.NoError(t, tx.Commit())
+
+	tx, err = db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM users")
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	require.NoError(t, audit.Err())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var recs [2]dbwrap.AuditRecord
+
+	for i, l := range lines {
+		require.NoError(t, json.Unmarshal([]byte(l), &recs[i]))
+	}
+
+	assert.Equal(t, dbwrap.Exec, recs[0].Operation)
+	assert.Equal(t, "UPDATE users SET password = ? WHERE id = ?", recs[0].Statement)
+	assert.Equal(t, "***", recs[0].Args[0].Value)
+	assert.Equal(t, int64(1), recs[0].Args[1].Value)
+	assert.Equal(t, "alice", recs[0].Actor)
+	assert.Equal(t, "bool64/dbwrap_test.TestWithAuditLog", recs[0].Caller)
+	assert.Equal(t, dbwrap.AuditAutocommit, recs[0].Outcome)
+	assert.Equal(t, int64(0), recs[0].Tx)
+	assert.Equal(t, "", recs[0].Prev)
+
+	assert.Equal(t, "DELETE FROM users WHERE id = ?", recs[1].Statement)
+	assert.Equal(t, dbwrap.AuditCommitted, recs[1].Outcome)
+	assert.NotEqual(t, int64(0), recs[1].Tx)
+	assert.Equal(t, recs[0].Hash, recs[1].Prev)
+	require.NotNil(t, recs[1].RowsAffected)
+
+	last, err := dbwrap.VerifyAuditLog(strings.NewReader(buf.String()), "")
+	require.NoError(t, err)
+	assert.Equal(t, recs[1].Hash, last)
+}
+
+func TestWithAuditLog_keepRolledBack(t *testing.T) {
+	var (
+		ctx = context.Background()
+		f   = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		buf bytes.Buffer
+	)
+
+	audit := dbwrap.NewAuditLog(&buf, dbwrap.AuditConfig{KeepRolledBack: true, PrevHash: "abc"})
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithAuditLog(audit)))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO t VALUES (?)", time.Now())
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	var rec dbwrap.AuditRecord
+
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	assert.Equal(t, dbwrap.AuditRolledBack, rec.Outcome)
+	assert.Equal(t, "abc", rec.Prev)
+	assert.Nil(t, rec.Args)
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+
+	audit := dbwrap.NewAuditLog(&buf, dbwrap.AuditConfig{
+		Redact: func(_ string, args []driver.NamedValue) []driver.NamedValue { return args },
+	})
+	f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithAuditLog(audit)))
+
+	for i := 0; i < 3; i++ {
+		_, err := db.Exec("INSERT INTO t VALUES (?, ?, ?, ?)", i, "a", []byte("b"), time.Now())
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, db.Close())
+
+	log := buf.String()
+
+	_, err := dbwrap.VerifyAuditLog(strings.NewReader(log), "")
+	require.NoError(t, err)
+
+	// Tampered record.
+	_, err = dbwrap.VerifyAuditLog(strings.NewReader(strings.Replace(log, `"seq":2,`, `"seq":2,"actor":"x",`, 1)), "")
+	assert.EqualError(t, err, "invalid audit line 2: audit record hash mismatch")
+
+	// Removed record.
+	lines := strings.SplitAfter(log, "\n")
+	_, err = dbwrap.VerifyAuditLog(strings.NewReader(lines[0]+lines[2]), "")
+	assert.EqualError(t, err, "invalid audit line 2: audit record is not chained to previous record")
+
+	// Removed head of log.
+	_, err = dbwrap.VerifyAuditLog(strings.NewReader(lines[1]+lines[2]), "")
+	assert.EqualError(t, err, "invalid audit line 1: audit record is not chained to previous record")
+
+	// Continued log.
+	var rec dbwrap.AuditRecord
+
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &rec))
+
+	_, err = dbwrap.VerifyAuditLog(strings.NewReader(lines[1]+lines[2]), rec.Hash)
+	require.NoError(t, err)
+}
+
+// skipConnector mimics a driver that executes statements with arguments only with prepared statements.
+type skipConnector struct {
+	driver.Connector
+}
+
+func (c skipConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return skipConn{Conn: conn}, nil
+}
+
+type skipConn struct {
+	driver.Conn
+}
+
+func (c skipConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) > 0 {
+		return nil, driver.ErrSkip
+	}
+
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+}
+
+func TestWithAuditLog_skip(t *testing.T) {
+	var (
+		f   = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		buf bytes.Buffer
+	)
+
+	audit := dbwrap.NewAuditLog(&buf, dbwrap.AuditConfig{})
+	db := sql.OpenDB(dbwrap.WrapConnector(skipConnector{Connector: f.Connector()}, dbwrap.WithAuditLog(audit)))
+
+	_, err := db.Exec("DELETE FROM t WHERE id = ?", 1)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+
+	var rec dbwrap.AuditRecord
+
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &rec))
+	assert.Equal(t, dbwrap.StmtExec, rec.Operation)
+	assert.Empty(t, rec.Error)
+}
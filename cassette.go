@@ -0,0 +1,439 @@
+package dbwrap
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// CassetteVersion is a version of cassette format produced by Recorder.
+const CassetteVersion = 1
+
+// Cassette is a recording of database operations.
+//
+// Cassette is stored in JSON Lines format, the first line is a header with version,
+// every following line is an Interaction.
+type Cassette struct {
+	Version      int
+	Interactions []Interaction
+}
+
+// cassetteHeader is the first line of cassette.
+type cassetteHeader struct {
+	Version int `json:"version"`
+}
+
+// Interaction is a recorded database operation with its response.
+type Interaction struct {
+	// Seq is an order of operation start, interactions are written
+	// when they finish, so order of lines may differ from Seq.
+	Seq       int             `json:"seq"`
+	Operation Operation       `json:"operation"`
+	Statement string          `json:"statement,omitempty"`
+	Args      []CassetteValue `json:"args,omitempty"`
+	Error     string          `json:"error,omitempty"`
+
+	// Result is available for Exec and StmtExec.
+	Result *CassetteResult `json:"result,omitempty"`
+
+	// Columns and Rows are available for Query and StmtQuery.
+	Columns []CassetteColumn  `json:"columns,omitempty"`
+	Rows    [][]CassetteValue `json:"rows,omitempty"`
+	// RowsError is an error that interrupted reading of rows.
+	RowsError string `json:"rowsError,omitempty"`
+}
+
+// CassetteResult is a recorded driver.Result.
+type CassetteResult struct {
+	LastInsertID      int64  `json:"lastInsertId"`
+	LastInsertIDError string `json:"lastInsertIdError,omitempty"`
+	RowsAffected      int64  `json:"rowsAffected"`
+	RowsAffectedError string `json:"rowsAffectedError,omitempty"`
+}
+
+// CassetteColumn is a recorded column metadata.
+//
+// Optional values are nil when driver does not provide them.
+type CassetteColumn struct {
+	Name             string `json:"name"`
+	DatabaseTypeName string `json:"databaseTypeName,omitempty"`
+	Length           *int64 `json:"length,omitempty"`
+	Nullable         *bool  `json:"nullable,omitempty"`
+	Precision        *int64 `json:"precision,omitempty"`
+	Scale            *int64 `json:"scale,omitempty"`
+
+	// ScanType is a name of Go type of column values, see driver.RowsColumnTypeScanType.
+	ScanType string `json:"scanType,omitempty"`
+
+	// scanType is available for columns of current process.
+	scanType reflect.Type
+}
+
+// scanTypes resolves ScanType of loaded columns.
+var scanTypes = func() map[string]reflect.Type {
+	res := map[string]reflect.Type{}
+
+	for _, v := range []interface{}{
+		new(interface{}), new(bool), new(string), new([]byte), new(time.Time),
+		new(int), new(int8), new(int16), new(int32), new(int64),
+		new(uint), new(uint8), new(uint16), new(uint32), new(uint64),
+		new(float32), new(float64), new(sql.RawBytes),
+		new(sql.NullBool), new(sql.NullFloat64), new(sql.NullInt64), new(sql.NullString),
+	} {
+		t := reflect.TypeOf(v).Elem()
+		res[t.String()] = t
+	}
+
+	return res
+}()
+
+// CassetteValue is a typed driver.Value with its name and ordinal for arguments.
+type CassetteValue struct {
+	Name    string
+	Ordinal int
+	Value   driver.Value
+}
+
+type cassetteValue struct {
+	Name    string          `json:"name,omitempty"`
+	Ordinal int             `json:"ordinal,omitempty"`
+	Type    string          `json:"type"`
+	Value   json.RawMessage `json:"value,omitempty"`
+}
+
+// These are types of cassette values.
+const (
+	typeNull   = "null"
+	typeInt    = "int64"
+	typeFloat  = "float64"
+	typeBool   = "bool"
+	typeBytes  = "bytes"
+	typeString = "string"
+	typeTime   = "time"
+	typeText   = "text"
+)
+
+// CassetteText is a text representation of a value that is not a driver.Value,
+// such values can be passed to driver that implements driver.NamedValueChecker.
+type CassetteText string
+
+// MarshalJSON encodes value with its type.
+func (v CassetteValue) MarshalJSON() ([]byte, error) {
+	var (
+		cv  = cassetteValue{Name: v.Name, Ordinal: v.Ordinal}
+		val interface{}
+	)
+
+	switch x := v.Value.(type) {
+	case nil:
+		cv.Type = typeNull
+	case int64:
+		cv.Type = typeInt
+		val = strconv.FormatInt(x, 10)
+	case float64:
+		cv.Type = typeFloat
+		val = x
+	case bool:
+		cv.Type = typeBool
+		val = x
+	case []byte:
+		cv.Type = typeBytes
+		val = x
+	case string:
+		cv.Type = typeString
+		val = x
+	case time.Time:
+		cv.Type = typeTime
+		val = x.Format(time.RFC3339Nano)
+	case CassetteText:
+		cv.Type = typeText
+		val = string(x)
+	default:
+		return nil, fmt.Errorf("unsupported driver value type %T", v.Value)
+	}
+
+	if val != nil {
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+
+		cv.Value = raw
+	}
+
+	return json.Marshal(cv)
+}
+
+// UnmarshalJSON decodes typed value.
+func (v *CassetteValue) UnmarshalJSON(data []byte) error {
+	var cv cassetteValue
+
+	if err := json.Unmarshal(data, &cv); err != nil {
+		return err
+	}
+
+	v.Name = cv.Name
+	v.Ordinal = cv.Ordinal
+
+	var err error
+
+	switch cv.Type {
+	case typeNull:
+		v.Value = nil
+	case typeInt:
+		var s string
+		if err = json.Unmarshal(cv.Value, &s); err == nil {
+			v.Value, err = strconv.ParseInt(s, 10, 64)
+		}
+	case typeFloat:
+		var f float64
+		err = json.Unmarshal(cv.Value, &f)
+		v.Value = f
+	case typeBool:
+		var b bool
+		err = json.Unmarshal(cv.Value, &b)
+		v.Value = b
+	case typeBytes:
+		var b []byte
+		err = json.Unmarshal(cv.Value, &b)
+		v.Value = b
+	case typeString:
+		var s string
+		err = json.Unmarshal(cv.Value, &s)
+		v.Value = s
+	case typeTime:
+		var s string
+		if err = json.Unmarshal(cv.Value, &s); err == nil {
+			v.Value, err = time.Parse(time.RFC3339Nano, s)
+		}
+	case typeText:
+		var s string
+		err = json.Unmarshal(cv.Value, &s)
+		v.Value = CassetteText(s)
+	default:
+		err = fmt.Errorf("unsupported cassette value type %q", cv.Type)
+	}
+
+	return err
+}
+
+// String returns a text representation of value.
+func (v CassetteValue) String() string {
+	switch x := v.Value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return fmt.Sprintf("%q", x)
+	case string:
+		return strconv.Quote(x)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprintf("%v", x)
+	}
+}
+
+// cassetteValues converts named values to cassette values.
+func cassetteValues(args []driver.NamedValue) []CassetteValue {
+	if len(args) == 0 {
+		return nil
+	}
+
+	res := make([]CassetteValue, 0, len(args))
+
+	for _, a := range args {
+		v := a.Value
+
+		// Buffers may be reused by the caller.
+		if b, ok := v.([]byte); ok {
+			v = append([]byte(nil), b...)
+		}
+
+		if !driver.IsValue(v) {
+			v = CassetteText(fmt.Sprintf("%#v", v))
+		}
+
+		res = append(res, CassetteValue{Name: a.Name, Ordinal: a.Ordinal, Value: v})
+	}
+
+	return res
+}
+
+// LoadCassette reads cassette from JSON Lines.
+func LoadCassette(r io.Reader) (*Cassette, error) {
+	var (
+		c    Cassette
+		line int
+	)
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for s.Scan() {
+		line++
+
+		b := bytes.TrimSpace(s.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+
+		if c.Version == 0 {
+			var h cassetteHeader
+
+			if err := json.Unmarshal(b, &h); err != nil {
+				return nil, fmt.Errorf("failed to decode cassette header: %v", err)
+			}
+
+			if h.Version < 1 || h.Version > CassetteVersion {
+				return nil, fmt.Errorf("unsupported cassette version %d", h.Version)
+			}
+
+			c.Version = h.Version
+
+			continue
+		}
+
+		var i Interaction
+
+		if err := json.Unmarshal(b, &i); err != nil {
+			return nil, fmt.Errorf("failed to decode cassette line %d: %v", line, err)
+		}
+
+		c.Interactions = append(c.Interactions, i)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if c.Version == 0 {
+		return nil, errors.New("missing cassette header")
+	}
+
+	sort.SliceStable(c.Interactions, func(i, j int) bool {
+		return c.Interactions[i].Seq < c.Interactions[j].Seq
+	})
+
+	return &c, nil
+}
+
+// cassetteError returns error message to store in cassette.
+func cassetteError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+// replayedError restores error from cassette, well known driver errors are restored by identity.
+func replayedError(msg string) error {
+	switch msg {
+	case "":
+		return nil
+	case driver.ErrSkip.Error():
+		return driver.ErrSkip
+	case driver.ErrBadConn.Error():
+		return driver.ErrBadConn
+	case io.EOF.Error():
+		return io.EOF
+	default:
+		return errors.New(msg)
+	}
+}
+
+// replayRows implements driver.Rows with rows of interaction.
+type replayRows struct {
+	i   *Interaction
+	pos int
+}
+
+func (r *replayRows) Columns() []string {
+	res := make([]string, len(r.i.Columns))
+
+	for j, c := range r.i.Columns {
+		res[j] = c.Name
+	}
+
+	return res
+}
+
+func (r *replayRows) Close() error {
+	return nil
+}
+
+func (r *replayRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.i.Rows) {
+		if err := replayedError(r.i.RowsError); err != nil {
+			return err
+		}
+
+		return io.EOF
+	}
+
+	for j, v := range r.i.Rows[r.pos] {
+		if b, ok := v.Value.([]byte); ok {
+			dest[j] = append([]byte(nil), b...)
+		} else {
+			dest[j] = v.Value
+		}
+	}
+
+	r.pos++
+
+	return nil
+}
+
+// ColumnTypeScanType returns recorded scan type,
+// unknown type is reported as interface{} like database/sql does for drivers without scan types.
+func (r *replayRows) ColumnTypeScanType(index int) reflect.Type {
+	c := r.i.Columns[index]
+
+	if c.scanType != nil {
+		return c.scanType
+	}
+
+	if t, ok := scanTypes[c.ScanType]; ok {
+		return t
+	}
+
+	return scanTypes["interface {}"]
+}
+
+func (r *replayRows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.i.Columns[index].DatabaseTypeName
+}
+
+func (r *replayRows) ColumnTypeLength(index int) (length int64, ok bool) {
+	if l := r.i.Columns[index].Length; l != nil {
+		return *l, true
+	}
+
+	return 0, false
+}
+
+func (r *replayRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if n := r.i.Columns[index].Nullable; n != nil {
+		return *n, true
+	}
+
+	return false, false
+}
+
+func (r *replayRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	c := r.i.Columns[index]
+	if c.Precision != nil && c.Scale != nil {
+		return *c.Precision, *c.Scale, true
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,207 @@
+// Code generated by internal/composegen. DO NOT EDIT.
+
+//go:build go1.10
+// +build go1.10
+
+package dbwrap
+
+import (
+	"database/sql/driver"
+)
+
+// withValidator is a method set of driver.Validator that can be embedded with conn.
+type withValidator interface {
+	IsValid() bool
+}
+
+// composeConn returns conn that implements optional interfaces enabled by flags.
+//
+//nolint:funlen,gocyclo,maintidx // Generated code.
+func composeConn(c *wConn, parent driver.Conn) conn {
+	mask := 0
+
+	if _, ok := parent.(driver.NamedValueChecker); ok {
+		mask |= 1 << 0
+	}
+
+	if resetsSession(parent, c.options) {
+		mask |= 1 << 1
+	}
+
+	if _, ok := parent.(withValidator); ok {
+		mask |= 1 << 2
+	}
+
+	switch mask {
+	case 0: // none
+		return struct {
+			conn
+		}{c}
+	case 1: // NamedValueChecker
+		return struct {
+			conn
+			driver.NamedValueChecker
+		}{c, parent.(driver.NamedValueChecker)}
+	case 2: // SessionResetter
+		return struct {
+			conn
+			driver.SessionResetter
+		}{c, c}
+	case 3: // NamedValueChecker, SessionResetter
+		return struct {
+			conn
+			driver.NamedValueChecker
+			driver.SessionResetter
+		}{c, parent.(driver.NamedValueChecker), c}
+	case 4: // Validator
+		return struct {
+			conn
+			withValidator
+		}{c, parent.(withValidator)}
+	case 5: // NamedValueChecker, Validator
+		return struct {
+			conn
+			driver.NamedValueChecker
+			withValidator
+		}{c, parent.(driver.NamedValueChecker), parent.(withValidator)}
+	case 6: // SessionResetter, Validator
+		return struct {
+			conn
+			driver.SessionResetter
+			withValidator
+		}{c, c, parent.(withValidator)}
+	case 7: // NamedValueChecker, SessionResetter, Validator
+		return struct {
+			conn
+			driver.NamedValueChecker
+			driver.SessionResetter
+			withValidator
+		}{c, parent.(driver.NamedValueChecker), c, parent.(withValidator)}
+	}
+
+	panic("unreachable")
+}
+
+// composeStmt returns driver.Stmt that implements optional interfaces enabled by flags.
+//
+//nolint:funlen,gocyclo,maintidx // Generated code.
+func composeStmt(s wStmt, parent driver.Stmt) driver.Stmt {
+	mask := 0
+
+	if _, ok := parent.(driver.StmtExecContext); ok {
+		mask |= 1 << 0
+	}
+
+	if _, ok := parent.(driver.StmtQueryContext); ok {
+		mask |= 1 << 1
+	}
+
+	if _, ok := parent.(driver.ColumnConverter); ok {
+		mask |= 1 << 2
+	}
+
+	if _, ok := parent.(driver.NamedValueChecker); ok {
+		mask |= 1 << 3
+	}
+
+	switch mask {
+	case 0: // none
+		return struct {
+			driver.Stmt
+		}{s}
+	case 1: // StmtExecContext
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+		}{s, s}
+	case 2: // StmtQueryContext
+		return struct {
+			driver.Stmt
+			driver.StmtQueryContext
+		}{s, s}
+	case 3: // StmtExecContext, StmtQueryContext
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			driver.StmtQueryContext
+		}{s, s, s}
+	case 4: // ColumnConverter
+		return struct {
+			driver.Stmt
+			withColumnConverter
+		}{s, parent.(withColumnConverter)}
+	case 5: // StmtExecContext, ColumnConverter
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			withColumnConverter
+		}{s, s, parent.(withColumnConverter)}
+	case 6: // StmtQueryContext, ColumnConverter
+		return struct {
+			driver.Stmt
+			driver.StmtQueryContext
+			withColumnConverter
+		}{s, s, parent.(withColumnConverter)}
+	case 7: // StmtExecContext, StmtQueryContext, ColumnConverter
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			driver.StmtQueryContext
+			withColumnConverter
+		}{s, s, s, parent.(withColumnConverter)}
+	case 8: // NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.NamedValueChecker
+		}{s, parent.(driver.NamedValueChecker)}
+	case 9: // StmtExecContext, NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			driver.NamedValueChecker
+		}{s, s, parent.(driver.NamedValueChecker)}
+	case 10: // StmtQueryContext, NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.StmtQueryContext
+			driver.NamedValueChecker
+		}{s, s, parent.(driver.NamedValueChecker)}
+	case 11: // StmtExecContext, StmtQueryContext, NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			driver.StmtQueryContext
+			driver.NamedValueChecker
+		}{s, s, s, parent.(driver.NamedValueChecker)}
+	case 12: // ColumnConverter, NamedValueChecker
+		return struct {
+			driver.Stmt
+			withColumnConverter
+			driver.NamedValueChecker
+		}{s, parent.(withColumnConverter), parent.(driver.NamedValueChecker)}
+	case 13: // StmtExecContext, ColumnConverter, NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			withColumnConverter
+			driver.NamedValueChecker
+		}{s, s, parent.(withColumnConverter), parent.(driver.NamedValueChecker)}
+	case 14: // StmtQueryContext, ColumnConverter, NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.StmtQueryContext
+			withColumnConverter
+			driver.NamedValueChecker
+		}{s, s, parent.(withColumnConverter), parent.(driver.NamedValueChecker)}
+	case 15: // StmtExecContext, StmtQueryContext, ColumnConverter, NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			driver.StmtQueryContext
+			withColumnConverter
+			driver.NamedValueChecker
+		}{s, s, s, parent.(withColumnConverter), parent.(driver.NamedValueChecker)}
+	}
+
+	panic("unreachable")
+}
@@ -0,0 +1,165 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	connInterfaces = []reflect.Type{
+		reflect.TypeOf((*driver.Pinger)(nil)).Elem(),
+		reflect.TypeOf((*driver.Execer)(nil)).Elem(), //nolint:staticcheck // Deprecated interface is still optional.
+		reflect.TypeOf((*driver.ExecerContext)(nil)).Elem(),
+		reflect.TypeOf((*driver.Queryer)(nil)).Elem(), //nolint:staticcheck // Deprecated interface is still optional.
+		reflect.TypeOf((*driver.QueryerContext)(nil)).Elem(),
+		reflect.TypeOf((*driver.ConnPrepareContext)(nil)).Elem(),
+		reflect.TypeOf((*driver.ConnBeginTx)(nil)).Elem(),
+		reflect.TypeOf((*driver.NamedValueChecker)(nil)).Elem(),
+		reflect.TypeOf((*driver.SessionResetter)(nil)).Elem(),
+		// driver.Validator is not available in Go 1.11.
+		reflect.TypeOf((*interface{ IsValid() bool })(nil)).Elem(),
+	}
+
+	stmtInterfaces = []reflect.Type{
+		reflect.TypeOf((*driver.StmtExecContext)(nil)).Elem(),
+		reflect.TypeOf((*driver.StmtQueryContext)(nil)).Elem(),
+		reflect.TypeOf((*driver.ColumnConverter)(nil)).Elem(), //nolint:staticcheck // Deprecated interface is still optional.
+		reflect.TypeOf((*driver.NamedValueChecker)(nil)).Elem(),
+	}
+
+	rowsInterfaces = []reflect.Type{
+		reflect.TypeOf((*driver.RowsNextResultSet)(nil)).Elem(),
+		reflect.TypeOf((*driver.RowsColumnTypeScanType)(nil)).Elem(),
+		reflect.TypeOf((*driver.RowsColumnTypeDatabaseTypeName)(nil)).Elem(),
+		reflect.TypeOf((*driver.RowsColumnTypeLength)(nil)).Elem(),
+		reflect.TypeOf((*driver.RowsColumnTypeNullable)(nil)).Elem(),
+		reflect.TypeOf((*driver.RowsColumnTypePrecisionScale)(nil)).Elem(),
+	}
+)
+
+// assertPreserved checks that wrapped value implements every optional interface of parent,
+// exact check also requires that wrapped value does not implement other optional interfaces.
+func assertPreserved(t *testing.T, ifaces []reflect.Type, parent, wrapped interface{}, exact bool, msg string) {
+	t.Helper()
+
+	for _, i := range ifaces {
+		if reflect.TypeOf(parent).Implements(i) {
+			assert.True(t, reflect.TypeOf(wrapped).Implements(i), "%s: %s is lost", msg, i)
+		} else if exact {
+			assert.False(t, reflect.TypeOf(wrapped).Implements(i), "%s: %s is added", msg, i)
+		}
+	}
+}
+
+func TestCapabilityMatrix(t *testing.T) {
+	ctx := context.Background()
+
+	for _, caps := range dbwraptest.CapabilityCombinations() {
+		f := dbwraptest.NewFake(caps)
+		parent := f.Conn()
+		noop := dbwrap.WithMiddleware(func(
+			ctx context.Context,
+			operation dbwrap.Operation,
+			statement string,
+			args []driver.NamedValue,
+		) (context.Context, func(error)) {
+			return ctx, nil
+		})
+
+		opened, err := dbwrap.Wrap(f.Driver(), noop).Open("")
+		require.NoError(t, err)
+
+		connected, err := dbwrap.WrapConnector(f.Connector(), noop).Connect(ctx)
+		require.NoError(t, err)
+
+		for name, c := range map[string]driver.Conn{
+			"Open":     opened,
+			"Connect":  connected,
+			"WrapConn": dbwrap.WrapConn(f.Conn(), noop),
+		} {
+			msg := fmt.Sprintf("%s %+v", name, caps)
+			assertPreserved(t, connInterfaces, parent, c, false, msg)
+			// NamedValueChecker, SessionResetter and Validator are composed by parent, others are always implemented.
+			assertPreserved(t, connInterfaces[7:], parent, c, true, msg)
+			assert.NotNil(t, dbwrap.HandleOf(c), msg)
+
+			ps, err := parent.Prepare("SELECT 1")
+			require.NoError(t, err)
+
+			s, err := c.Prepare("SELECT 1")
+			require.NoError(t, err)
+			assertPreserved(t, stmtInterfaces, ps, s, true, msg)
+
+			pr, err := ps.Query(nil) //nolint:staticcheck // Deprecated method is available for all statements.
+			require.NoError(t, err)
+
+			r, err := s.Query(nil) //nolint:staticcheck // Deprecated method is available for all statements.
+			require.NoError(t, err)
+			assertPreserved(t, rowsInterfaces, pr, r, true, msg)
+
+			require.NoError(t, pr.Close())
+			require.NoError(t, r.Close())
+			require.NoError(t, ps.Close())
+			require.NoError(t, s.Close())
+			require.NoError(t, c.Close())
+		}
+	}
+}
+
+func TestCapabilityMatrix_legacyExecer(t *testing.T) {
+	noop := dbwrap.WithMiddleware(func(
+		ctx context.Context,
+		operation dbwrap.Operation,
+		statement string,
+		args []driver.NamedValue,
+	) (context.Context, func(error)) {
+		return ctx, nil
+	})
+
+	for _, caps := range dbwraptest.CapabilityCombinations() {
+		for _, cacheSize := range []int{0, 2} {
+			f := dbwraptest.NewFake(caps)
+			msg := fmt.Sprintf("cache %d %+v", cacheSize, caps)
+
+			db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), noop, dbwrap.WithStmtCache(cacheSize)))
+
+			_, err := db.Exec("UPDATE t SET a = ?", 1)
+			require.NoError(t, err, msg)
+
+			rows, err := db.Query("SELECT a FROM t WHERE b = ?", 1)
+			require.NoError(t, err, msg)
+			require.NoError(t, rows.Close(), msg)
+			require.NoError(t, db.Close(), msg)
+
+			methods := f.Methods()
+
+			switch {
+			case caps.ExecerContext:
+				assert.Contains(t, methods, "Conn.ExecContext", msg)
+			case caps.Execer && cacheSize > 0:
+				assert.Contains(t, methods, "Conn.Exec", msg)
+			default:
+				// Without statement cache legacy execer is skipped in favor of prepared statement as before.
+				assert.NotContains(t, methods, "Conn.Exec", msg)
+			}
+
+			switch {
+			case caps.QueryerContext:
+				assert.Contains(t, methods, "Conn.QueryContext", msg)
+			case caps.Queryer && cacheSize > 0:
+				assert.Contains(t, methods, "Conn.Query", msg)
+			default:
+				assert.NotContains(t, methods, "Conn.Query", msg)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package dbwrap
+
+import (
+	"context"
+	"regexp"
+)
+
+// Condition reports whether middleware should be applied to operation.
+type Condition func(ctx context.Context, operation Operation, statement string) bool
+
+// middlewareFilter limits middleware of Options.Middlewares with the same index.
+type middlewareFilter struct {
+	// operations are applicable operations, nil for operations of Options.
+	operations map[Operation]bool
+
+	condition Condition
+}
+
+// applies checks if middleware should be applied to operation.
+func (f middlewareFilter) applies(ctx context.Context, operation Operation, statement string) bool {
+	if f.operations != nil && !f.operations[operation] {
+		return false
+	}
+
+	return f.condition == nil || f.condition(ctx, operation, statement)
+}
+
+// withMiddlewareFilter adds middlewares with a filter to a db wrapper.
+func withMiddlewareFilter(f middlewareFilter, mw []Middleware) Option {
+	return func(o *Options) {
+		for len(o.filters) < len(o.Middlewares) {
+			o.filters = append(o.filters, middlewareFilter{})
+		}
+
+		for _, m := range mw {
+			o.Middlewares = append(o.Middlewares, m)
+			o.filters = append(o.filters, f)
+		}
+	}
+}
+
+// WithMiddlewareFor adds middlewares that are only applied to listed operations,
+// operations are enabled regardless of WithOperations.
+func WithMiddlewareFor(ops []Operation, mw ...Middleware) Option {
+	f := middlewareFilter{operations: make(map[Operation]bool, len(ops))}
+
+	for _, op := range ops {
+		f.operations[op] = true
+	}
+
+	return withMiddlewareFilter(f, mw)
+}
+
+// WithMiddlewareIf adds middlewares that are only applied when condition is met.
+func WithMiddlewareIf(cond Condition, mw ...Middleware) Option {
+	return withMiddlewareFilter(middlewareFilter{condition: cond}, mw)
+}
+
+// AllConditions is met when all conditions are met.
+func AllConditions(conds ...Condition) Condition {
+	return func(ctx context.Context, operation Operation, statement string) bool {
+		for _, c := range conds {
+			if !c(ctx, operation, statement) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// StatementMatches is met when statement matches regular expression,
+// operations without statement, like Begin or RowsClose, inherit statement of parent operation if any.
+func StatementMatches(re *regexp.Regexp) Condition {
+	return func(ctx context.Context, operation Operation, statement string) bool {
+		if statement == "" {
+			if p, ok := ctx.Value(statementCtxKey{}).(*opState); ok {
+				statement = p.text
+			}
+		}
+
+		return re.MatchString(statement)
+	}
+}
+
+// CallerMatches is met when caller of operation matches regular expression, see CallerCtx.
+func CallerMatches(re *regexp.Regexp) Condition {
+	return func(ctx context.Context, operation Operation, statement string) bool {
+		return re.MatchString(CallerCtx(ctx))
+	}
+}
+
+type flagCtxKey string
+
+// WithFlag adds a named flag to context, see ContextFlag.
+func WithFlag(ctx context.Context, flag string) context.Context {
+	return context.WithValue(ctx, flagCtxKey(flag), true)
+}
+
+// ContextFlag is met when context has a flag, see WithFlag.
+func ContextFlag(flag string) Condition {
+	return func(ctx context.Context, operation Operation, statement string) bool {
+		return ctx.Value(flagCtxKey(flag)) != nil
+	}
+}
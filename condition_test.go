@@ -0,0 +1,64 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMiddlewareFor(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		f    = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		seen = map[string][]dbwrap.Operation{}
+	)
+
+	mw := func(name string) dbwrap.Middleware {
+		return func(
+			ctx context.Context,
+			operation dbwrap.Operation,
+			statement string,
+			args []driver.NamedValue,
+		) (context.Context, func(error)) {
+			seen[name] = append(seen[name], operation)
+
+			return ctx, nil
+		}
+	}
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(),
+		dbwrap.WithOperations(dbwrap.Exec, dbwrap.Query),
+		dbwrap.WithMiddleware(mw("all")),
+		dbwrap.WithMiddlewareFor([]dbwrap.Operation{dbwrap.Exec, dbwrap.RowsClose}, mw("for")),
+		dbwrap.WithMiddlewareIf(dbwrap.ContextFlag("debug"), mw("flag")),
+		dbwrap.WithMiddlewareIf(dbwrap.AllConditions(
+			dbwrap.StatementMatches(regexp.MustCompile(`^SELECT`)),
+			dbwrap.CallerMatches(regexp.MustCompile(`TestWithMiddlewareFor$`)),
+		), mw("select")),
+	))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err := db.ExecContext(dbwrap.WithFlag(ctx, "debug"), "DELETE FROM t")
+	require.NoError(t, err)
+
+	rows, err := db.QueryContext(ctx, "SELECT * FROM t")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	assert.Equal(t, map[string][]dbwrap.Operation{
+		"all":    {dbwrap.Exec, dbwrap.Query},
+		"for":    {dbwrap.Exec, dbwrap.RowsClose},
+		"flag":   {dbwrap.Exec},
+		"select": {dbwrap.Query},
+	}, seen)
+}
@@ -0,0 +1,88 @@
+package dbwraptest
+
+// Capabilities toggles optional interfaces of fake driver, connections, statements and rows.
+//
+// Connection methods of disabled Pinger, Execer, Queryer, ConnPrepareContext and ConnBeginTx
+// are still implemented, but they behave as database/sql does for connection without them.
+type Capabilities struct {
+	// Driver.
+	DriverContext bool
+
+	// Connection.
+	Pinger                bool
+	Execer                bool
+	ExecerContext         bool
+	Queryer               bool
+	QueryerContext        bool
+	ConnPrepareContext    bool
+	ConnBeginTx           bool
+	ConnNamedValueChecker bool
+	SessionResetter       bool
+	Validator             bool
+
+	// Statement.
+	StmtExecContext       bool
+	StmtQueryContext      bool
+	ColumnConverter       bool
+	StmtNamedValueChecker bool
+
+	// Rows.
+	RowsNextResultSet              bool
+	RowsColumnTypeScanType         bool
+	RowsColumnTypeDatabaseTypeName bool
+	RowsColumnTypeLength           bool
+	RowsColumnTypeNullable         bool
+	RowsColumnTypePrecisionScale   bool
+}
+
+// AllCapabilities returns Capabilities with all optional interfaces enabled.
+func AllCapabilities() Capabilities {
+	var caps Capabilities
+
+	for _, flags := range caps.flags() {
+		for _, f := range flags {
+			*f = true
+		}
+	}
+
+	return caps
+}
+
+// CapabilityCombinations returns every combination of optional interfaces
+// for driver, connection, statement and rows, one entity at a time.
+//
+// Optional interfaces of other entities are enabled in every combination.
+func CapabilityCombinations() []Capabilities {
+	var res []Capabilities
+
+	for entity, flags := range new(Capabilities).flags() {
+		for mask := 0; mask < 1<<uint(len(flags)); mask++ {
+			caps := AllCapabilities()
+
+			for i, f := range caps.flags()[entity] {
+				*f = mask&(1<<uint(i)) != 0
+			}
+
+			res = append(res, caps)
+		}
+	}
+
+	return res
+}
+
+// flags returns flags of driver, connection, statement and rows.
+func (caps *Capabilities) flags() [][]*bool {
+	return [][]*bool{
+		{&caps.DriverContext},
+		{
+			&caps.Pinger, &caps.Execer, &caps.ExecerContext, &caps.Queryer, &caps.QueryerContext,
+			&caps.ConnPrepareContext, &caps.ConnBeginTx, &caps.ConnNamedValueChecker, &caps.SessionResetter,
+			&caps.Validator,
+		},
+		{&caps.StmtExecContext, &caps.StmtQueryContext, &caps.ColumnConverter, &caps.StmtNamedValueChecker},
+		{
+			&caps.RowsNextResultSet, &caps.RowsColumnTypeScanType, &caps.RowsColumnTypeDatabaseTypeName,
+			&caps.RowsColumnTypeLength, &caps.RowsColumnTypeNullable, &caps.RowsColumnTypePrecisionScale,
+		},
+	}
+}
@@ -0,0 +1,199 @@
+package dbwraptest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/bool64/dbwrap"
+)
+
+// UpdateGoldenEnv is the name of environment variable that enables update of golden files.
+const UpdateGoldenEnv = "DBWRAPTEST_UPDATE_GOLDEN"
+
+// Recorder captures statements and transaction boundaries for assertions in tests.
+type Recorder struct {
+	Trace
+
+	// DB is a database instrumented with Recorder.
+	DB *sql.DB
+}
+
+// Capture instruments database with Recorder.
+//
+// Recorder.DB is a new database that runs operations on connections of db.
+// Additional options are applied after capturing middleware.
+func Capture(db *sql.DB, options ...dbwrap.Option) *Recorder {
+	return CaptureConnector(dbConnector{db: db}, options...)
+}
+
+// CaptureConnector opens database with connector instrumented with Recorder.
+//
+// Unlike Capture, it wraps driver connections directly, so optional interfaces of driver are preserved.
+// Additional options are applied after capturing middleware.
+func CaptureConnector(c driver.Connector, options ...dbwrap.Option) *Recorder {
+	r := &Recorder{}
+
+	options = append([]dbwrap.Option{r.Option(), dbwrap.WithOperations(capturedOperations...)}, options...)
+	r.DB = sql.OpenDB(dbwrap.WrapConnector(c, options...))
+
+	return r
+}
+
+// capturedOperations are operations with statements and transaction boundaries.
+var capturedOperations = []dbwrap.Operation{
+	dbwrap.Exec, dbwrap.Query, dbwrap.Prepare, dbwrap.StmtExec, dbwrap.StmtQuery,
+	dbwrap.Begin, dbwrap.Commit, dbwrap.Rollback,
+}
+
+// AssertCount checks the number of captured operations.
+func (r *Recorder) AssertCount(tb TestingT, operation dbwrap.Operation, expected int) bool {
+	tb.Helper()
+
+	actual := 0
+
+	for _, e := range r.Entries() {
+		if e.Operation == operation {
+			actual++
+		}
+	}
+
+	if actual != expected {
+		tb.Errorf("unexpected count of %s: expected %d, actual %d", operation, expected, actual)
+
+		return false
+	}
+
+	return true
+}
+
+// AssertStatementMatches checks that at least one captured statement matches regular expression.
+func (r *Recorder) AssertStatementMatches(tb TestingT, pattern string) bool {
+	tb.Helper()
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		tb.Errorf("invalid pattern %q: %v", pattern, err)
+
+		return false
+	}
+
+	var statements []string
+
+	for _, e := range r.Entries() {
+		if e.Statement == "" {
+			continue
+		}
+
+		if re.MatchString(e.Statement) {
+			return true
+		}
+
+		statements = append(statements, e.Statement)
+	}
+
+	tb.Errorf("no statement matches %q, captured:\n%s", pattern, strings.Join(statements, "\n"))
+
+	return false
+}
+
+// AssertNoQueriesOutsideTx checks that every captured statement was executed in transaction.
+func (r *Recorder) AssertNoQueriesOutsideTx(tb TestingT) bool {
+	tb.Helper()
+
+	for i, e := range r.Entries() {
+		if e.Statement != "" && !e.InTx {
+			tb.Errorf("operation %d %s is outside of transaction: %s", i, e.Operation, e.Statement)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// Golden checks captured operations against a golden file.
+//
+// Golden file is written if UpdateGoldenEnv environment variable is set,
+// missing golden file is reported as failure otherwise.
+// Every operation is a line with operation name and statement with collapsed whitespace,
+// statements in transaction are indented.
+func (r *Recorder) Golden(tb TestingT, filename string) bool {
+	tb.Helper()
+
+	actual := r.golden()
+
+	expected, err := ioutil.ReadFile(filename) //nolint:gosec // Test helper reads file by design.
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := ioutil.WriteFile(filename, []byte(actual), 0600); err != nil {
+			tb.Errorf("failed to write golden file: %v", err)
+
+			return false
+		}
+
+		return true
+	}
+
+	if os.IsNotExist(err) {
+		tb.Errorf("golden file %s is missing (set %s=1 to create)", filename, UpdateGoldenEnv)
+
+		return false
+	}
+
+	if err != nil {
+		tb.Errorf("failed to read golden file: %v", err)
+
+		return false
+	}
+
+	if string(expected) == actual {
+		return true
+	}
+
+	var (
+		el = strings.Split(string(expected), "\n")
+		al = strings.Split(actual, "\n")
+	)
+
+	for i := 0; ; i++ {
+		if i >= len(el) || i >= len(al) || el[i] != al[i] {
+			var e, a string
+
+			if i < len(el) {
+				e = el[i]
+			}
+
+			if i < len(al) {
+				a = al[i]
+			}
+
+			tb.Errorf("captured operations differ from %s (set %s=1 to update) at line %d\n- expected: %s\n+ actual:   %s",
+				filename, UpdateGoldenEnv, i+1, e, a)
+
+			return false
+		}
+	}
+}
+
+func (r *Recorder) golden() string {
+	b := strings.Builder{}
+
+	for _, e := range r.Entries() {
+		if e.InTx && e.Operation != dbwrap.Commit && e.Operation != dbwrap.Rollback {
+			b.WriteString("  ")
+		}
+
+		b.WriteString(string(e.Operation))
+
+		if e.Statement != "" {
+			b.WriteString(" " + strings.Join(strings.Fields(e.Statement), " "))
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package dbwraptest_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapture(t *testing.T) {
+	ctx := context.Background()
+	rec := dbwraptest.CaptureConnector(dbwraptest.NewFake(dbwraptest.AllCapabilities()).Connector())
+
+	defer func() {
+		require.NoError(t, rec.DB.Close())
+	}()
+
+	tx, err := rec.DB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(ctx, "UPDATE t\n  SET a = ?", 1)
+	require.NoError(t, err)
+
+	rows, err := tx.QueryContext(ctx, "SELECT a FROM t")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM t WHERE a = ?")
+	require.NoError(t, err)
+
+	_, err = stmt.ExecContext(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, stmt.Close())
+	require.NoError(t, tx.Commit())
+
+	rec.AssertCount(t, dbwrap.Exec, 1)
+	rec.AssertCount(t, dbwrap.StmtExec, 1)
+	rec.AssertStatementMatches(t, `^UPDATE t\s+SET`)
+	rec.AssertNoQueriesOutsideTx(t)
+	rec.Golden(t, "testdata/queries.golden")
+
+	_, err = rec.DB.ExecContext(ctx, "DELETE FROM t")
+	require.NoError(t, err)
+
+	mt := &mockT{}
+	assert.False(t, rec.AssertNoQueriesOutsideTx(mt))
+	assert.Equal(t, "operation 6 exec is outside of transaction: DELETE FROM t", mt.err)
+
+	assert.False(t, rec.AssertCount(mt, dbwrap.Query, 3))
+	assert.Equal(t, "unexpected count of query: expected 3, actual 1", mt.err)
+
+	assert.False(t, rec.AssertStatementMatches(mt, "INSERT"))
+	assert.Contains(t, mt.err, `no statement matches "INSERT"`)
+
+	assert.False(t, rec.Golden(mt, "testdata/queries.golden"))
+	assert.Equal(t, "captured operations differ from testdata/queries.golden "+
+		"(set DBWRAPTEST_UPDATE_GOLDEN=1 to update) at line 7\n"+
+		"- expected: \n"+
+		"+ actual:   exec DELETE FROM t", mt.err)
+
+	if os.Getenv(dbwraptest.UpdateGoldenEnv) == "" {
+		assert.False(t, rec.Golden(mt, "testdata/missing.golden"))
+		assert.Equal(t, "golden file testdata/missing.golden is missing (set DBWRAPTEST_UPDATE_GOLDEN=1 to create)", mt.err)
+	}
+}
+
+func TestCapture_db(t *testing.T) {
+	ctx := context.Background()
+	f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+
+	f.Query = func(ctx context.Context, statement string, args []driver.NamedValue) (*dbwraptest.Rows, error) {
+		return dbwraptest.NewRows("a", "b").AddRow(int64(1), "foo").AddRow(int64(2), "bar"), nil
+	}
+
+	db := sql.OpenDB(f.Connector())
+	rec := dbwraptest.Capture(db)
+
+	defer func() {
+		require.NoError(t, rec.DB.Close())
+		require.NoError(t, db.Close())
+	}()
+
+	stmt, err := rec.DB.PrepareContext(ctx, "DELETE FROM t WHERE a = ?")
+	require.NoError(t, err)
+
+	tx, err := rec.DB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(ctx, "UPDATE t SET a = ?", 1)
+	require.NoError(t, err)
+
+	_, err = tx.StmtContext(ctx, stmt).ExecContext(ctx, 2)
+	require.NoError(t, err)
+
+	rows, err := tx.QueryContext(ctx, "SELECT a, b FROM t WHERE b = ?", sql.Named("b", "foo"))
+	require.NoError(t, err)
+
+	var values []string
+
+	for rows.Next() {
+		var (
+			a int
+			b string
+		)
+
+		require.NoError(t, rows.Scan(&a, &b))
+
+		values = append(values, fmt.Sprintf("%d:%s", a, b))
+	}
+
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	require.NoError(t, tx.Commit())
+	require.NoError(t, stmt.Close())
+
+	assert.Equal(t, []string{"1:foo", "2:bar"}, values)
+	rec.AssertCount(t, dbwrap.Exec, 1)
+	rec.AssertCount(t, dbwrap.StmtExec, 1)
+	rec.AssertCount(t, dbwrap.Query, 1)
+
+	var statements []string
+
+	for _, c := range f.Calls() {
+		if c.Statement != "" {
+			statements = append(statements, c.Method+" "+c.Statement)
+		}
+	}
+
+	assert.Contains(t, statements, "Conn.ExecContext UPDATE t SET a = ?")
+	assert.Contains(t, statements, "Conn.QueryContext SELECT a, b FROM t WHERE b = ?")
+	assert.Contains(t, statements, "Stmt.ExecContext DELETE FROM t WHERE a = ?")
+}
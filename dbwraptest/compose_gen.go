@@ -0,0 +1,922 @@
+// Code generated by internal/composegen. DO NOT EDIT.
+
+package dbwraptest
+
+import (
+	"database/sql/driver"
+	"reflect"
+)
+
+// composeDriver returns driver.Driver that implements optional interfaces enabled by flags.
+//
+//nolint:funlen,gocyclo,maintidx // Generated code.
+func composeDriver(d *fakeDriver, caps Capabilities) driver.Driver {
+	mask := 0
+
+	if caps.DriverContext {
+		mask |= 1 << 0
+	}
+
+	switch mask {
+	case 0: // none
+		return struct {
+			driver.Driver
+		}{d}
+	case 1: // DriverContext
+		return struct {
+			driver.Driver
+			driver.DriverContext
+		}{d, d}
+	}
+
+	panic("unreachable")
+}
+
+// withValidator is a method set of driver.Validator that can be embedded with fakeConn.
+type withValidator interface {
+	IsValid() bool
+}
+
+// composeConn returns fakeConn that implements optional interfaces enabled by flags.
+//
+//nolint:funlen,gocyclo,maintidx // Generated code.
+func composeConn(c *conn, caps Capabilities) fakeConn {
+	mask := 0
+
+	if caps.ExecerContext {
+		mask |= 1 << 0
+	}
+
+	if caps.QueryerContext {
+		mask |= 1 << 1
+	}
+
+	if caps.ConnNamedValueChecker {
+		mask |= 1 << 2
+	}
+
+	if caps.SessionResetter {
+		mask |= 1 << 3
+	}
+
+	if caps.Validator {
+		mask |= 1 << 4
+	}
+
+	switch mask {
+	case 0: // none
+		return struct {
+			fakeConn
+		}{c}
+	case 1: // ExecerContext
+		return struct {
+			fakeConn
+			driver.ExecerContext
+		}{c, c}
+	case 2: // QueryerContext
+		return struct {
+			fakeConn
+			driver.QueryerContext
+		}{c, c}
+	case 3: // ExecerContext, QueryerContext
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.QueryerContext
+		}{c, c, c}
+	case 4: // NamedValueChecker
+		return struct {
+			fakeConn
+			driver.NamedValueChecker
+		}{c, c}
+	case 5: // ExecerContext, NamedValueChecker
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.NamedValueChecker
+		}{c, c, c}
+	case 6: // QueryerContext, NamedValueChecker
+		return struct {
+			fakeConn
+			driver.QueryerContext
+			driver.NamedValueChecker
+		}{c, c, c}
+	case 7: // ExecerContext, QueryerContext, NamedValueChecker
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.QueryerContext
+			driver.NamedValueChecker
+		}{c, c, c, c}
+	case 8: // SessionResetter
+		return struct {
+			fakeConn
+			driver.SessionResetter
+		}{c, c}
+	case 9: // ExecerContext, SessionResetter
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.SessionResetter
+		}{c, c, c}
+	case 10: // QueryerContext, SessionResetter
+		return struct {
+			fakeConn
+			driver.QueryerContext
+			driver.SessionResetter
+		}{c, c, c}
+	case 11: // ExecerContext, QueryerContext, SessionResetter
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.QueryerContext
+			driver.SessionResetter
+		}{c, c, c, c}
+	case 12: // NamedValueChecker, SessionResetter
+		return struct {
+			fakeConn
+			driver.NamedValueChecker
+			driver.SessionResetter
+		}{c, c, c}
+	case 13: // ExecerContext, NamedValueChecker, SessionResetter
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.NamedValueChecker
+			driver.SessionResetter
+		}{c, c, c, c}
+	case 14: // QueryerContext, NamedValueChecker, SessionResetter
+		return struct {
+			fakeConn
+			driver.QueryerContext
+			driver.NamedValueChecker
+			driver.SessionResetter
+		}{c, c, c, c}
+	case 15: // ExecerContext, QueryerContext, NamedValueChecker, SessionResetter
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.QueryerContext
+			driver.NamedValueChecker
+			driver.SessionResetter
+		}{c, c, c, c, c}
+	case 16: // Validator
+		return struct {
+			fakeConn
+			withValidator
+		}{c, c}
+	case 17: // ExecerContext, Validator
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			withValidator
+		}{c, c, c}
+	case 18: // QueryerContext, Validator
+		return struct {
+			fakeConn
+			driver.QueryerContext
+			withValidator
+		}{c, c, c}
+	case 19: // ExecerContext, QueryerContext, Validator
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.QueryerContext
+			withValidator
+		}{c, c, c, c}
+	case 20: // NamedValueChecker, Validator
+		return struct {
+			fakeConn
+			driver.NamedValueChecker
+			withValidator
+		}{c, c, c}
+	case 21: // ExecerContext, NamedValueChecker, Validator
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.NamedValueChecker
+			withValidator
+		}{c, c, c, c}
+	case 22: // QueryerContext, NamedValueChecker, Validator
+		return struct {
+			fakeConn
+			driver.QueryerContext
+			driver.NamedValueChecker
+			withValidator
+		}{c, c, c, c}
+	case 23: // ExecerContext, QueryerContext, NamedValueChecker, Validator
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.QueryerContext
+			driver.NamedValueChecker
+			withValidator
+		}{c, c, c, c, c}
+	case 24: // SessionResetter, Validator
+		return struct {
+			fakeConn
+			driver.SessionResetter
+			withValidator
+		}{c, c, c}
+	case 25: // ExecerContext, SessionResetter, Validator
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.SessionResetter
+			withValidator
+		}{c, c, c, c}
+	case 26: // QueryerContext, SessionResetter, Validator
+		return struct {
+			fakeConn
+			driver.QueryerContext
+			driver.SessionResetter
+			withValidator
+		}{c, c, c, c}
+	case 27: // ExecerContext, QueryerContext, SessionResetter, Validator
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.QueryerContext
+			driver.SessionResetter
+			withValidator
+		}{c, c, c, c, c}
+	case 28: // NamedValueChecker, SessionResetter, Validator
+		return struct {
+			fakeConn
+			driver.NamedValueChecker
+			driver.SessionResetter
+			withValidator
+		}{c, c, c, c}
+	case 29: // ExecerContext, NamedValueChecker, SessionResetter, Validator
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.NamedValueChecker
+			driver.SessionResetter
+			withValidator
+		}{c, c, c, c, c}
+	case 30: // QueryerContext, NamedValueChecker, SessionResetter, Validator
+		return struct {
+			fakeConn
+			driver.QueryerContext
+			driver.NamedValueChecker
+			driver.SessionResetter
+			withValidator
+		}{c, c, c, c, c}
+	case 31: // ExecerContext, QueryerContext, NamedValueChecker, SessionResetter, Validator
+		return struct {
+			fakeConn
+			driver.ExecerContext
+			driver.QueryerContext
+			driver.NamedValueChecker
+			driver.SessionResetter
+			withValidator
+		}{c, c, c, c, c, c}
+	}
+
+	panic("unreachable")
+}
+
+// withColumnConverter is a method set of driver.ColumnConverter that can be embedded with driver.Stmt.
+type withColumnConverter interface {
+	ColumnConverter(idx int) driver.ValueConverter
+}
+
+// composeStmt returns driver.Stmt that implements optional interfaces enabled by flags.
+//
+//nolint:funlen,gocyclo,maintidx // Generated code.
+func composeStmt(s *stmt, caps Capabilities) driver.Stmt {
+	mask := 0
+
+	if caps.StmtExecContext {
+		mask |= 1 << 0
+	}
+
+	if caps.StmtQueryContext {
+		mask |= 1 << 1
+	}
+
+	if caps.ColumnConverter {
+		mask |= 1 << 2
+	}
+
+	if caps.StmtNamedValueChecker {
+		mask |= 1 << 3
+	}
+
+	switch mask {
+	case 0: // none
+		return struct {
+			driver.Stmt
+		}{s}
+	case 1: // StmtExecContext
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+		}{s, s}
+	case 2: // StmtQueryContext
+		return struct {
+			driver.Stmt
+			driver.StmtQueryContext
+		}{s, s}
+	case 3: // StmtExecContext, StmtQueryContext
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			driver.StmtQueryContext
+		}{s, s, s}
+	case 4: // ColumnConverter
+		return struct {
+			driver.Stmt
+			withColumnConverter
+		}{s, s}
+	case 5: // StmtExecContext, ColumnConverter
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			withColumnConverter
+		}{s, s, s}
+	case 6: // StmtQueryContext, ColumnConverter
+		return struct {
+			driver.Stmt
+			driver.StmtQueryContext
+			withColumnConverter
+		}{s, s, s}
+	case 7: // StmtExecContext, StmtQueryContext, ColumnConverter
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			driver.StmtQueryContext
+			withColumnConverter
+		}{s, s, s, s}
+	case 8: // NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.NamedValueChecker
+		}{s, s}
+	case 9: // StmtExecContext, NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			driver.NamedValueChecker
+		}{s, s, s}
+	case 10: // StmtQueryContext, NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.StmtQueryContext
+			driver.NamedValueChecker
+		}{s, s, s}
+	case 11: // StmtExecContext, StmtQueryContext, NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			driver.StmtQueryContext
+			driver.NamedValueChecker
+		}{s, s, s, s}
+	case 12: // ColumnConverter, NamedValueChecker
+		return struct {
+			driver.Stmt
+			withColumnConverter
+			driver.NamedValueChecker
+		}{s, s, s}
+	case 13: // StmtExecContext, ColumnConverter, NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			withColumnConverter
+			driver.NamedValueChecker
+		}{s, s, s, s}
+	case 14: // StmtQueryContext, ColumnConverter, NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.StmtQueryContext
+			withColumnConverter
+			driver.NamedValueChecker
+		}{s, s, s, s}
+	case 15: // StmtExecContext, StmtQueryContext, ColumnConverter, NamedValueChecker
+		return struct {
+			driver.Stmt
+			driver.StmtExecContext
+			driver.StmtQueryContext
+			withColumnConverter
+			driver.NamedValueChecker
+		}{s, s, s, s, s}
+	}
+
+	panic("unreachable")
+}
+
+// withRowsNextResultSet is a method set of driver.RowsNextResultSet that can be embedded with driver.Rows.
+type withRowsNextResultSet interface {
+	HasNextResultSet() bool
+	NextResultSet() error
+}
+
+// withRowsColumnTypeScanType is a method set of driver.RowsColumnTypeScanType that can be embedded with driver.Rows.
+type withRowsColumnTypeScanType interface {
+	ColumnTypeScanType(index int) reflect.Type
+}
+
+// withRowsColumnTypeDatabaseTypeName is a method set of driver.RowsColumnTypeDatabaseTypeName that can be embedded with driver.Rows.
+type withRowsColumnTypeDatabaseTypeName interface {
+	ColumnTypeDatabaseTypeName(index int) string
+}
+
+// withRowsColumnTypeLength is a method set of driver.RowsColumnTypeLength that can be embedded with driver.Rows.
+type withRowsColumnTypeLength interface {
+	ColumnTypeLength(index int) (length int64, ok bool)
+}
+
+// withRowsColumnTypeNullable is a method set of driver.RowsColumnTypeNullable that can be embedded with driver.Rows.
+type withRowsColumnTypeNullable interface {
+	ColumnTypeNullable(index int) (nullable, ok bool)
+}
+
+// withRowsColumnTypePrecisionScale is a method set of driver.RowsColumnTypePrecisionScale that can be embedded with driver.Rows.
+type withRowsColumnTypePrecisionScale interface {
+	ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool)
+}
+
+// composeRows returns driver.Rows that implements optional interfaces enabled by flags.
+//
+//nolint:funlen,gocyclo,maintidx // Generated code.
+func composeRows(r *rows, caps Capabilities) driver.Rows {
+	mask := 0
+
+	if caps.RowsNextResultSet {
+		mask |= 1 << 0
+	}
+
+	if caps.RowsColumnTypeScanType {
+		mask |= 1 << 1
+	}
+
+	if caps.RowsColumnTypeDatabaseTypeName {
+		mask |= 1 << 2
+	}
+
+	if caps.RowsColumnTypeLength {
+		mask |= 1 << 3
+	}
+
+	if caps.RowsColumnTypeNullable {
+		mask |= 1 << 4
+	}
+
+	if caps.RowsColumnTypePrecisionScale {
+		mask |= 1 << 5
+	}
+
+	switch mask {
+	case 0: // none
+		return struct {
+			driver.Rows
+		}{r}
+	case 1: // RowsNextResultSet
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+		}{r, r}
+	case 2: // RowsColumnTypeScanType
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+		}{r, r}
+	case 3: // RowsNextResultSet, RowsColumnTypeScanType
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+		}{r, r, r}
+	case 4: // RowsColumnTypeDatabaseTypeName
+		return struct {
+			driver.Rows
+			withRowsColumnTypeDatabaseTypeName
+		}{r, r}
+	case 5: // RowsNextResultSet, RowsColumnTypeDatabaseTypeName
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeDatabaseTypeName
+		}{r, r, r}
+	case 6: // RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+		}{r, r, r}
+	case 7: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+		}{r, r, r, r}
+	case 8: // RowsColumnTypeLength
+		return struct {
+			driver.Rows
+			withRowsColumnTypeLength
+		}{r, r}
+	case 9: // RowsNextResultSet, RowsColumnTypeLength
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeLength
+		}{r, r, r}
+	case 10: // RowsColumnTypeScanType, RowsColumnTypeLength
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeLength
+		}{r, r, r}
+	case 11: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeLength
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeLength
+		}{r, r, r, r}
+	case 12: // RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength
+		return struct {
+			driver.Rows
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+		}{r, r, r}
+	case 13: // RowsNextResultSet, RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+		}{r, r, r, r}
+	case 14: // RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+		}{r, r, r, r}
+	case 15: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+		}{r, r, r, r, r}
+	case 16: // RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsColumnTypeNullable
+		}{r, r}
+	case 17: // RowsNextResultSet, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeNullable
+		}{r, r, r}
+	case 18: // RowsColumnTypeScanType, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeNullable
+		}{r, r, r}
+	case 19: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeNullable
+		}{r, r, r, r}
+	case 20: // RowsColumnTypeDatabaseTypeName, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeNullable
+		}{r, r, r}
+	case 21: // RowsNextResultSet, RowsColumnTypeDatabaseTypeName, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeNullable
+		}{r, r, r, r}
+	case 22: // RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeNullable
+		}{r, r, r, r}
+	case 23: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeNullable
+		}{r, r, r, r, r}
+	case 24: // RowsColumnTypeLength, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+		}{r, r, r}
+	case 25: // RowsNextResultSet, RowsColumnTypeLength, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+		}{r, r, r, r}
+	case 26: // RowsColumnTypeScanType, RowsColumnTypeLength, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+		}{r, r, r, r}
+	case 27: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeLength, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+		}{r, r, r, r, r}
+	case 28: // RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+		}{r, r, r, r}
+	case 29: // RowsNextResultSet, RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+		}{r, r, r, r, r}
+	case 30: // RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+		}{r, r, r, r, r}
+	case 31: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength, RowsColumnTypeNullable
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+		}{r, r, r, r, r, r}
+	case 32: // RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypePrecisionScale
+		}{r, r}
+	case 33: // RowsNextResultSet, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypePrecisionScale
+		}{r, r, r}
+	case 34: // RowsColumnTypeScanType, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypePrecisionScale
+		}{r, r, r}
+	case 35: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r}
+	case 36: // RowsColumnTypeDatabaseTypeName, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypePrecisionScale
+		}{r, r, r}
+	case 37: // RowsNextResultSet, RowsColumnTypeDatabaseTypeName, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r}
+	case 38: // RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r}
+	case 39: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r}
+	case 40: // RowsColumnTypeLength, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeLength
+			withRowsColumnTypePrecisionScale
+		}{r, r, r}
+	case 41: // RowsNextResultSet, RowsColumnTypeLength, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeLength
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r}
+	case 42: // RowsColumnTypeScanType, RowsColumnTypeLength, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeLength
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r}
+	case 43: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeLength, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeLength
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r}
+	case 44: // RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r}
+	case 45: // RowsNextResultSet, RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r}
+	case 46: // RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r}
+	case 47: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r, r}
+	case 48: // RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r}
+	case 49: // RowsNextResultSet, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r}
+	case 50: // RowsColumnTypeScanType, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r}
+	case 51: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r}
+	case 52: // RowsColumnTypeDatabaseTypeName, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r}
+	case 53: // RowsNextResultSet, RowsColumnTypeDatabaseTypeName, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r}
+	case 54: // RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r}
+	case 55: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r, r}
+	case 56: // RowsColumnTypeLength, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r}
+	case 57: // RowsNextResultSet, RowsColumnTypeLength, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r}
+	case 58: // RowsColumnTypeScanType, RowsColumnTypeLength, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r}
+	case 59: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeLength, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r, r}
+	case 60: // RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r}
+	case 61: // RowsNextResultSet, RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r, r}
+	case 62: // RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r, r}
+	case 63: // RowsNextResultSet, RowsColumnTypeScanType, RowsColumnTypeDatabaseTypeName, RowsColumnTypeLength, RowsColumnTypeNullable, RowsColumnTypePrecisionScale
+		return struct {
+			driver.Rows
+			withRowsNextResultSet
+			withRowsColumnTypeScanType
+			withRowsColumnTypeDatabaseTypeName
+			withRowsColumnTypeLength
+			withRowsColumnTypeNullable
+			withRowsColumnTypePrecisionScale
+		}{r, r, r, r, r, r, r}
+	}
+
+	panic("unreachable")
+}
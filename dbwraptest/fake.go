@@ -0,0 +1,520 @@
+// Package dbwraptest provides test helpers for middlewares of database driver wrapper.
+package dbwraptest
+
+//go:generate go run ../internal/composegen -target dbwraptest -out compose_gen.go
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Fake is a scriptable in-memory database driver.
+//
+// Responses are defined with optional handlers, every call to the driver is logged.
+// Optional interfaces of driver, connections, statements and rows are enabled with Capabilities.
+type Fake struct {
+	Capabilities Capabilities
+
+	// Exec handles Exec and StmtExec, empty result is returned by default.
+	Exec func(ctx context.Context, statement string, args []driver.NamedValue) (driver.Result, error)
+
+	// Query handles Query and StmtQuery, empty rows are returned by default.
+	Query func(ctx context.Context, statement string, args []driver.NamedValue) (*Rows, error)
+
+	// Prepare handles Prepare.
+	Prepare func(ctx context.Context, statement string) error
+
+	// Ping handles Ping.
+	Ping func(ctx context.Context) error
+
+	// Begin handles Begin.
+	Begin func(ctx context.Context, opts driver.TxOptions) error
+
+	// Commit handles Commit.
+	Commit func() error
+
+	// Rollback handles Rollback.
+	Rollback func() error
+
+	// ResetSession handles ResetSession.
+	ResetSession func(ctx context.Context) error
+
+	// IsValid handles IsValid, connection is valid if it is nil.
+	// IsValid is not logged, as database/sql calls it whenever connection is returned to pool.
+	IsValid func() bool
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Call is a logged call to the fake driver.
+type Call struct {
+	// Method is a name of called method prefixed with entity, for example "Conn.QueryContext".
+	Method    string
+	Statement string
+	Args      []driver.NamedValue
+}
+
+// NewFake creates fake driver with capabilities.
+func NewFake(caps Capabilities) *Fake {
+	return &Fake{Capabilities: caps}
+}
+
+// Driver returns fake driver.Driver.
+func (f *Fake) Driver() driver.Driver {
+	return composeDriver(&fakeDriver{f: f}, f.Capabilities)
+}
+
+// Connector returns fake driver.Connector.
+func (f *Fake) Connector() driver.Connector {
+	return &connector{f: f}
+}
+
+// Conn returns new fake driver.Conn.
+func (f *Fake) Conn() driver.Conn {
+	return composeConn(&conn{f: f}, f.Capabilities)
+}
+
+// Calls returns logged calls.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Call(nil), f.calls...)
+}
+
+// Methods returns names of logged methods.
+func (f *Fake) Methods() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	res := make([]string, 0, len(f.calls))
+
+	for _, c := range f.calls {
+		res = append(res, c.Method)
+	}
+
+	return res
+}
+
+// Reset clears logged calls.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = nil
+}
+
+func (f *Fake) log(method, statement string, args []driver.NamedValue) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, Call{Method: method, Statement: statement, Args: args})
+}
+
+func (f *Fake) exec(ctx context.Context, method, statement string, args []driver.NamedValue) (driver.Result, error) {
+	f.log(method, statement, args)
+
+	if f.Exec != nil {
+		return f.Exec(ctx, statement, args)
+	}
+
+	return driver.RowsAffected(0), nil
+}
+
+func (f *Fake) query(ctx context.Context, method, statement string, args []driver.NamedValue) (driver.Rows, error) {
+	f.log(method, statement, args)
+
+	data := &Rows{}
+
+	if f.Query != nil {
+		var err error
+
+		if data, err = f.Query(ctx, statement, args); err != nil {
+			return nil, err
+		}
+	}
+
+	return composeRows(&rows{f: f, data: data}, f.Capabilities), nil
+}
+
+// Rows is a scripted result set.
+type Rows struct {
+	Columns []Column
+	Values  [][]driver.Value
+
+	// Err is returned by Next after values instead of io.EOF.
+	Err error
+
+	// NextSet is the next result set.
+	NextSet *Rows
+}
+
+// Column describes column of Rows.
+type Column struct {
+	Name             string
+	DatabaseTypeName string
+	ScanType         reflect.Type
+	Length           int64
+	Nullable         bool
+	Precision        int64
+	Scale            int64
+}
+
+// NewRows creates Rows with named columns.
+func NewRows(columns ...string) *Rows {
+	r := &Rows{}
+
+	for _, c := range columns {
+		r.Columns = append(r.Columns, Column{Name: c})
+	}
+
+	return r
+}
+
+// AddRow adds row values.
+func (r *Rows) AddRow(values ...driver.Value) *Rows {
+	r.Values = append(r.Values, values)
+
+	return r
+}
+
+// fakeDriver implements driver.Driver.
+type fakeDriver struct {
+	f *Fake
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.f.log("Driver.Open", name, nil)
+
+	return d.f.Conn(), nil
+}
+
+func (d *fakeDriver) OpenConnector(name string) (driver.Connector, error) {
+	d.f.log("Driver.OpenConnector", name, nil)
+
+	return &connector{f: d.f}, nil
+}
+
+// connector implements driver.Connector.
+type connector struct {
+	f *Fake
+}
+
+func (c *connector) Connect(_ context.Context) (driver.Conn, error) {
+	c.f.log("Connector.Connect", "", nil)
+
+	return c.f.Conn(), nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.f.Driver()
+}
+
+// fakeConn is a method set of every fake connection.
+//
+// Capabilities Pinger, Execer, Queryer, ConnPrepareContext and ConnBeginTx are checked per call,
+// disabled method behaves like database/sql does for connection without the interface.
+// Other optional interfaces change behavior of wrapper, so they are composed with composeConn.
+type fakeConn interface {
+	driver.Conn
+	driver.Pinger
+	driver.Execer  //nolint:staticcheck // Deprecated interface is still optional.
+	driver.Queryer //nolint:staticcheck // Deprecated interface is still optional.
+	driver.ConnPrepareContext
+	driver.ConnBeginTx
+}
+
+// conn implements driver.Conn and all its optional interfaces.
+type conn struct {
+	f *Fake
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if !c.f.Capabilities.Pinger {
+		// Connection without driver.Pinger is considered alive by database/sql.
+		return nil
+	}
+
+	c.f.log("Conn.Ping", "", nil)
+
+	if c.f.Ping != nil {
+		return c.f.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *conn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	if !c.f.Capabilities.Execer {
+		return nil, driver.ErrSkip
+	}
+
+	return c.f.exec(context.Background(), "Conn.Exec", query, namedValues(args))
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.f.exec(ctx, "Conn.ExecContext", query, args)
+}
+
+func (c *conn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	if !c.f.Capabilities.Queryer {
+		return nil, driver.ErrSkip
+	}
+
+	return c.f.query(context.Background(), "Conn.Query", query, namedValues(args))
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.f.query(ctx, "Conn.QueryContext", query, args)
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.prepare(context.Background(), "Conn.Prepare", query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if !c.f.Capabilities.ConnPrepareContext {
+		return c.prepare(ctx, "Conn.Prepare", query)
+	}
+
+	return c.prepare(ctx, "Conn.PrepareContext", query)
+}
+
+func (c *conn) prepare(ctx context.Context, method, query string) (driver.Stmt, error) {
+	c.f.log(method, query, nil)
+
+	if c.f.Prepare != nil {
+		if err := c.f.Prepare(ctx, query); err != nil {
+			return nil, err
+		}
+	}
+
+	return composeStmt(&stmt{f: c.f, query: query}, c.f.Capabilities), nil
+}
+
+func (c *conn) Close() error {
+	c.f.log("Conn.Close", "", nil)
+
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.begin(context.Background(), "Conn.Begin", driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if !c.f.Capabilities.ConnBeginTx {
+		// Errors are the same as database/sql returns for driver without driver.ConnBeginTx.
+		if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+			return nil, errors.New("sql: driver does not support non-default isolation level")
+		}
+
+		if opts.ReadOnly {
+			return nil, errors.New("sql: driver does not support read-only transactions")
+		}
+
+		return c.begin(ctx, "Conn.Begin", opts)
+	}
+
+	return c.begin(ctx, "Conn.BeginTx", opts)
+}
+
+func (c *conn) begin(ctx context.Context, method string, opts driver.TxOptions) (driver.Tx, error) {
+	c.f.log(method, "", nil)
+
+	if c.f.Begin != nil {
+		if err := c.f.Begin(ctx, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	return &tx{f: c.f}, nil
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) (err error) {
+	nv.Value, err = driver.DefaultParameterConverter.ConvertValue(nv.Value)
+
+	return err
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	c.f.log("Conn.ResetSession", "", nil)
+
+	if c.f.ResetSession != nil {
+		return c.f.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if c.f.IsValid != nil {
+		return c.f.IsValid()
+	}
+
+	return true
+}
+
+// stmt implements driver.Stmt and all its optional interfaces.
+type stmt struct {
+	f     *Fake
+	query string
+}
+
+func (s *stmt) Close() error {
+	s.f.log("Stmt.Close", s.query, nil)
+
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.f.exec(context.Background(), "Stmt.Exec", s.query, namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.f.query(context.Background(), "Stmt.Query", s.query, namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.f.exec(ctx, "Stmt.ExecContext", s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.f.query(ctx, "Stmt.QueryContext", s.query, args)
+}
+
+func (s *stmt) ColumnConverter(_ int) driver.ValueConverter {
+	return driver.DefaultParameterConverter
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) (err error) {
+	nv.Value, err = driver.DefaultParameterConverter.ConvertValue(nv.Value)
+
+	return err
+}
+
+// tx implements driver.Tx.
+type tx struct {
+	f *Fake
+}
+
+func (t *tx) Commit() error {
+	t.f.log("Tx.Commit", "", nil)
+
+	if t.f.Commit != nil {
+		return t.f.Commit()
+	}
+
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	t.f.log("Tx.Rollback", "", nil)
+
+	if t.f.Rollback != nil {
+		return t.f.Rollback()
+	}
+
+	return nil
+}
+
+// rows implements driver.Rows and all its optional interfaces.
+type rows struct {
+	f    *Fake
+	data *Rows
+	pos  int
+}
+
+func (r *rows) Columns() []string {
+	res := make([]string, 0, len(r.data.Columns))
+
+	for _, c := range r.data.Columns {
+		res = append(res, c.Name)
+	}
+
+	return res
+}
+
+func (r *rows) Close() error {
+	r.f.log("Rows.Close", "", nil)
+
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	r.f.log("Rows.Next", "", nil)
+
+	if r.pos >= len(r.data.Values) {
+		if r.data.Err != nil {
+			return r.data.Err
+		}
+
+		return io.EOF
+	}
+
+	copy(dest, r.data.Values[r.pos])
+	r.pos++
+
+	return nil
+}
+
+func (r *rows) HasNextResultSet() bool {
+	return r.data.NextSet != nil
+}
+
+func (r *rows) NextResultSet() error {
+	if r.data.NextSet == nil {
+		return io.EOF
+	}
+
+	r.data = r.data.NextSet
+	r.pos = 0
+
+	return nil
+}
+
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if t := r.data.Columns[index].ScanType; t != nil {
+		return t
+	}
+
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.data.Columns[index].DatabaseTypeName
+}
+
+func (r *rows) ColumnTypeLength(index int) (length int64, ok bool) {
+	return r.data.Columns[index].Length, true
+}
+
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return r.data.Columns[index].Nullable, true
+}
+
+func (r *rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	c := r.data.Columns[index]
+
+	return c.Precision, c.Scale, true
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	var nargs []driver.NamedValue
+
+	for i, a := range args {
+		nargs = append(nargs, driver.NamedValue{Ordinal: i + 1, Value: a})
+	}
+
+	return nargs
+}
@@ -0,0 +1,159 @@
+package dbwraptest_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFake_capabilities(t *testing.T) {
+	combinations := dbwraptest.CapabilityCombinations()
+	assert.Len(t, combinations, 2+1024+16+64)
+
+	for _, caps := range combinations {
+		f := dbwraptest.NewFake(caps)
+
+		_, ok := f.Driver().(driver.DriverContext)
+		assert.Equal(t, caps.DriverContext, ok)
+
+		c := f.Conn()
+
+		// Capabilities that are checked per call.
+		assert.True(t, is(c, (*driver.Pinger)(nil)))
+		assert.True(t, is(c, (*driver.Execer)(nil)))  //nolint:staticcheck
+		assert.True(t, is(c, (*driver.Queryer)(nil))) //nolint:staticcheck
+		assert.True(t, is(c, (*driver.ConnPrepareContext)(nil)))
+		assert.True(t, is(c, (*driver.ConnBeginTx)(nil)))
+
+		assert.Equal(t, caps.ExecerContext, is(c, (*driver.ExecerContext)(nil)))
+		assert.Equal(t, caps.QueryerContext, is(c, (*driver.QueryerContext)(nil)))
+		assert.Equal(t, caps.ConnNamedValueChecker, is(c, (*driver.NamedValueChecker)(nil)))
+		assert.Equal(t, caps.SessionResetter, is(c, (*driver.SessionResetter)(nil)))
+		assert.Equal(t, caps.Validator, is(c, (*interface{ IsValid() bool })(nil)))
+
+		s, err := c.Prepare("SELECT 1")
+		require.NoError(t, err)
+
+		assert.Equal(t, caps.StmtExecContext, is(s, (*driver.StmtExecContext)(nil)))
+		assert.Equal(t, caps.StmtQueryContext, is(s, (*driver.StmtQueryContext)(nil)))
+		assert.Equal(t, caps.ColumnConverter, is(s, (*driver.ColumnConverter)(nil))) //nolint:staticcheck
+		assert.Equal(t, caps.StmtNamedValueChecker, is(s, (*driver.NamedValueChecker)(nil)))
+
+		r, err := s.Query(nil) //nolint:staticcheck
+		require.NoError(t, err)
+
+		assert.Equal(t, caps.RowsNextResultSet, is(r, (*driver.RowsNextResultSet)(nil)))
+		assert.Equal(t, caps.RowsColumnTypeScanType, is(r, (*driver.RowsColumnTypeScanType)(nil)))
+		assert.Equal(t, caps.RowsColumnTypeDatabaseTypeName, is(r, (*driver.RowsColumnTypeDatabaseTypeName)(nil)))
+		assert.Equal(t, caps.RowsColumnTypeLength, is(r, (*driver.RowsColumnTypeLength)(nil)))
+		assert.Equal(t, caps.RowsColumnTypeNullable, is(r, (*driver.RowsColumnTypeNullable)(nil)))
+		assert.Equal(t, caps.RowsColumnTypePrecisionScale, is(r, (*driver.RowsColumnTypePrecisionScale)(nil)))
+	}
+}
+
+func TestNewFake_disabledCapabilities(t *testing.T) {
+	ctx := context.Background()
+	caps := dbwraptest.AllCapabilities()
+	caps.Pinger = false
+	caps.Execer = false
+	caps.ExecerContext = false
+	caps.ConnPrepareContext = false
+	caps.ConnBeginTx = false
+
+	f := dbwraptest.NewFake(caps)
+	db := sql.OpenDB(f.Connector())
+
+	require.NoError(t, db.PingContext(ctx))
+
+	_, err := db.ExecContext(ctx, "DELETE FROM t")
+	require.NoError(t, err)
+
+	_, err = db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	assert.EqualError(t, err, "sql: driver does not support read-only transactions")
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	require.NoError(t, db.Close())
+
+	assert.Equal(t, []string{
+		"Connector.Connect",
+		"Conn.ResetSession", "Conn.Prepare", "Stmt.ExecContext", "Stmt.Close",
+		"Conn.ResetSession", "Conn.ResetSession", "Conn.Begin", "Tx.Rollback",
+		"Conn.Close",
+	}, f.Methods())
+}
+
+// is checks whether v implements interface pointed by iface.
+func is(v interface{}, iface interface{}) bool {
+	return reflect.TypeOf(v).Implements(reflect.TypeOf(iface).Elem())
+}
+
+func TestTrace_AssertOperations(t *testing.T) {
+	var (
+		ctx = context.Background()
+		tr  = &dbwraptest.Trace{}
+		f   = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	)
+
+	f.Query = func(ctx context.Context, statement string, args []driver.NamedValue) (*dbwraptest.Rows, error) {
+		return dbwraptest.NewRows("a").AddRow(int64(1)), nil
+	}
+
+	f.Commit = func() error {
+		return errors.New("failed")
+	}
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), tr.Option(), dbwrap.WithAllOperations()))
+
+	var a int
+
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT a FROM t WHERE b = ?", 1).Scan(&a))
+	assert.Equal(t, 1, a)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM t")
+	require.NoError(t, err)
+	assert.EqualError(t, tx.Commit(), "failed")
+
+	tr.AssertOperations(t,
+		dbwrap.Query, dbwrap.RowsNext, dbwrap.RowsClose,
+		dbwrap.Begin, dbwrap.Exec, dbwrap.Commit,
+	)
+	tr.AssertFinished(t)
+
+	entries := tr.Entries()
+	assert.Equal(t, "commit (failed)", entries[len(entries)-1].String())
+
+	assert.Equal(t, []string{
+		"Connector.Connect",
+		"Conn.QueryContext", "Rows.Next", "Rows.Close",
+		"Conn.ResetSession", "Conn.BeginTx", "Conn.ExecContext", "Tx.Commit",
+	}, f.Methods())
+
+	mt := &mockT{}
+	assert.False(t, tr.AssertOperations(mt, dbwrap.Query))
+	assert.Equal(t, "unexpected operations\nexpected: [query]\n"+
+		"actual:   [query rows_next rows_close begin exec commit]", mt.err)
+}
+
+type mockT struct {
+	err string
+}
+
+func (m *mockT) Helper() {}
+
+func (m *mockT) Errorf(format string, args ...interface{}) {
+	m.err = fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,232 @@
+package dbwraptest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+)
+
+// dbConnector exposes *sql.DB as driver.Connector, every connection holds a *sql.Conn of database.
+type dbConnector struct {
+	db *sql.DB
+}
+
+func (c dbConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dbConn{conn: conn}, nil
+}
+
+func (c dbConnector) Driver() driver.Driver {
+	return dbDriver(c)
+}
+
+// dbDriver opens connections of *sql.DB, name is ignored.
+type dbDriver dbConnector
+
+func (d dbDriver) Open(_ string) (driver.Conn, error) {
+	return dbConnector(d).Connect(context.Background())
+}
+
+// querier is implemented by *sql.Conn and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// dbConn implements driver.Conn with *sql.Conn.
+type dbConn struct {
+	conn *sql.Conn
+	tx   *sql.Tx
+}
+
+func (c *dbConn) querier() querier {
+	if c.tx != nil {
+		return c.tx
+	}
+
+	return c.conn
+}
+
+// CheckNamedValue passes arguments to *sql.DB as is, so that they are converted by its driver.
+func (c *dbConn) CheckNamedValue(_ *driver.NamedValue) error {
+	return nil
+}
+
+func (c *dbConn) Ping(ctx context.Context) error {
+	return c.conn.PingContext(ctx)
+}
+
+func (c *dbConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.querier().ExecContext(ctx, query, sqlArgs(args)...)
+}
+
+func (c *dbConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return newDBRows(c.querier().QueryContext(ctx, query, sqlArgs(args)...))
+}
+
+func (c *dbConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *dbConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		st  *sql.Stmt
+		err error
+	)
+
+	if c.tx != nil {
+		st, err = c.tx.PrepareContext(ctx, query)
+	} else {
+		st, err = c.conn.PrepareContext(ctx, query)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dbStmt{c: c, st: st, tx: c.tx}, nil
+}
+
+func (c *dbConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *dbConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	tx, err := c.conn.BeginTx(ctx, &sql.TxOptions{Isolation: sql.IsolationLevel(opts.Isolation), ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+
+	c.tx = tx
+
+	return c, nil
+}
+
+func (c *dbConn) Commit() error {
+	tx := c.tx
+	c.tx = nil
+
+	return tx.Commit()
+}
+
+func (c *dbConn) Rollback() error {
+	tx := c.tx
+	c.tx = nil
+
+	return tx.Rollback()
+}
+
+func (c *dbConn) Close() error {
+	return c.conn.Close()
+}
+
+// dbStmt implements driver.Stmt with *sql.Stmt.
+type dbStmt struct {
+	c  *dbConn
+	st *sql.Stmt
+
+	// tx is a transaction of statement preparation.
+	tx *sql.Tx
+}
+
+func (s *dbStmt) stmt(ctx context.Context) *sql.Stmt {
+	if s.c.tx != nil && s.c.tx != s.tx {
+		return s.c.tx.StmtContext(ctx, s.st)
+	}
+
+	return s.st
+}
+
+func (s *dbStmt) Close() error {
+	return s.st.Close()
+}
+
+func (s *dbStmt) NumInput() int {
+	return -1
+}
+
+// CheckNamedValue passes arguments to *sql.DB as is, so that they are converted by its driver.
+func (s *dbStmt) CheckNamedValue(_ *driver.NamedValue) error {
+	return nil
+}
+
+func (s *dbStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *dbStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *dbStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.stmt(ctx).ExecContext(ctx, sqlArgs(args)...)
+}
+
+func (s *dbStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return newDBRows(s.stmt(ctx).QueryContext(ctx, sqlArgs(args)...))
+}
+
+// dbRows implements driver.Rows with *sql.Rows.
+type dbRows struct {
+	rows    *sql.Rows
+	columns []string
+}
+
+func newDBRows(rows *sql.Rows, err error) (driver.Rows, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		_ = rows.Close() //nolint:errcheck // Columns error is more important.
+
+		return nil, err
+	}
+
+	return &dbRows{rows: rows, columns: columns}, nil
+}
+
+func (r *dbRows) Columns() []string {
+	return r.columns
+}
+
+func (r *dbRows) Close() error {
+	return r.rows.Close()
+}
+
+func (r *dbRows) Next(dest []driver.Value) error {
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+
+		return io.EOF
+	}
+
+	ptrs := make([]interface{}, len(dest))
+	for i := range dest {
+		ptrs[i] = &dest[i]
+	}
+
+	return r.rows.Scan(ptrs...)
+}
+
+func sqlArgs(args []driver.NamedValue) []interface{} {
+	res := make([]interface{}, 0, len(args))
+
+	for _, a := range args {
+		if a.Name != "" {
+			res = append(res, sql.Named(a.Name, a.Value))
+		} else {
+			res = append(res, a.Value)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,161 @@
+package dbwraptest
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+
+	"github.com/bool64/dbwrap"
+)
+
+// TestingT is a subset of testing.TB.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Trace is a middleware that logs operations in order of invocation.
+type Trace struct {
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+// Entry is an operation seen by middleware.
+type Entry struct {
+	Operation dbwrap.Operation
+	Statement string
+	Args      []driver.NamedValue
+
+	// InTx is true when operation is executed in transaction.
+	InTx bool
+
+	// Finished is true when operation has finished, Err is the result of operation.
+	Finished bool
+	Err      error
+}
+
+// Option returns option to add trace middleware to a db wrapper.
+func (t *Trace) Option() dbwrap.Option {
+	return dbwrap.WithMiddleware(t.Middleware)
+}
+
+// Middleware logs operation.
+func (t *Trace) Middleware(
+	ctx context.Context,
+	operation dbwrap.Operation,
+	statement string,
+	args []driver.NamedValue,
+) (context.Context, func(error)) {
+	e := &Entry{
+		Operation: operation,
+		Statement: statement,
+		Args:      append([]driver.NamedValue(nil), args...),
+		InTx:      dbwrap.InTx(ctx),
+	}
+
+	t.mu.Lock()
+	t.entries = append(t.entries, e)
+	t.mu.Unlock()
+
+	return ctx, func(err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		e.Finished = true
+		e.Err = err
+	}
+}
+
+// Entries returns logged operations.
+func (t *Trace) Entries() []Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	res := make([]Entry, 0, len(t.entries))
+
+	for _, e := range t.entries {
+		res = append(res, *e)
+	}
+
+	return res
+}
+
+// Operations returns logged operations.
+func (t *Trace) Operations() []dbwrap.Operation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	res := make([]dbwrap.Operation, 0, len(t.entries))
+
+	for _, e := range t.entries {
+		res = append(res, e.Operation)
+	}
+
+	return res
+}
+
+// Reset clears logged operations.
+func (t *Trace) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.entries = nil
+}
+
+// AssertOperations checks that middleware has seen expected operations in order.
+func (t *Trace) AssertOperations(tb TestingT, expected ...dbwrap.Operation) bool {
+	tb.Helper()
+
+	actual := t.Operations()
+
+	if len(actual) == len(expected) {
+		same := true
+
+		for i := range actual {
+			if actual[i] != expected[i] {
+				same = false
+
+				break
+			}
+		}
+
+		if same {
+			return true
+		}
+	}
+
+	tb.Errorf("unexpected operations\nexpected: %v\nactual:   %v", expected, actual)
+
+	return false
+}
+
+// AssertFinished checks that every logged operation has finished.
+func (t *Trace) AssertFinished(tb TestingT) bool {
+	tb.Helper()
+
+	for i, e := range t.Entries() {
+		if !e.Finished {
+			tb.Errorf("operation %d %s is not finished: %s", i, e.Operation, e.Statement)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns a text representation of an entry.
+func (e Entry) String() string {
+	res := string(e.Operation)
+
+	if e.Statement != "" {
+		res += " " + e.Statement
+	}
+
+	if e.Err != nil {
+		res += fmt.Sprintf(" (%v)", e.Err)
+	}
+
+	return res
+}
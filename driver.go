@@ -5,12 +5,15 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"fmt"
 	"io"
-	"reflect"
 	"strconv"
 	"sync"
+	"sync/atomic"
 )
 
+//go:generate go run ./internal/composegen -target dbwrap-rows -out compose_rows_gen.go
+
 // Operation enumerates SQL operations.
 type Operation string
 
@@ -30,6 +33,10 @@ const (
 	RowsNext     = Operation("rows_next")
 	Commit       = Operation("commit")
 	Rollback     = Operation("rollback")
+
+	// StmtCacheHit and StmtCacheMiss are reported by statement cache, see WithStmtCache.
+	StmtCacheHit  = Operation("stmt_cache_hit")
+	StmtCacheMiss = Operation("stmt_cache_miss")
 )
 
 var defaultOperations = map[Operation]bool{
@@ -56,25 +63,21 @@ type conn interface {
 	driver.Conn
 	driver.ConnPrepareContext
 	driver.ConnBeginTx
+	handle() *Handle
 }
 
 var (
 	regMu sync.Mutex
 
 	// Compile time assertions.
-	_ driver.Driver                         = &wDriver{}
-	_ conn                                  = &wConn{}
-	_ driver.NamedValueChecker              = &wConn{}
-	_ driver.Result                         = &wResult{}
-	_ driver.Stmt                           = &wStmt{}
-	_ driver.StmtExecContext                = &wStmt{}
-	_ driver.StmtQueryContext               = &wStmt{}
-	_ driver.Rows                           = &wRows{}
-	_ driver.RowsNextResultSet              = &wRows{}
-	_ driver.RowsColumnTypeDatabaseTypeName = &wRows{}
-	_ driver.RowsColumnTypeLength           = &wRows{}
-	_ driver.RowsColumnTypeNullable         = &wRows{}
-	_ driver.RowsColumnTypePrecisionScale   = &wRows{}
+	_ driver.Driver            = &wDriver{}
+	_ conn                     = &wConn{}
+	_ driver.NamedValueChecker = &wConn{}
+	_ driver.Result            = &wResult{}
+	_ driver.Stmt              = &wStmt{}
+	_ driver.StmtExecContext   = &wStmt{}
+	_ driver.StmtQueryContext  = &wStmt{}
+	_ driver.Rows              = &wRows{}
 )
 
 // Register initializes and registers our wrapped database driver
@@ -93,46 +96,173 @@ func Register(driverName string, options ...Option) (string, error) {
 // It is possible to register multiple wrappers for the same database driver if
 // needing different Options for different connections.
 func RegisterWithSource(driverName string, source string, options ...Option) (string, error) {
-	// retrieve the driver implementation we need to wrap with instrumentation
-	db, err := sql.Open(driverName, source)
+	dri, err := parentDriver(driverName, source)
 	if err != nil {
 		return "", err
 	}
 
-	dri := db.Driver()
-
-	if err = db.Close(); err != nil {
-		return "", err
-	}
-
 	regMu.Lock()
 	defer regMu.Unlock()
 
+	registered := make(map[string]bool)
+
+	for _, name := range sql.Drivers() {
+		registered[name] = true
+	}
+
 	// Since we might want to register multiple drivers to have different
 	// Options, but potentially the same underlying database driver, we
 	// cycle through to find available driver names.
 	driverName += "-wrap-"
 
-	for i := int64(0); i < 100; i++ {
-		var (
-			found   = false
-			regName = driverName + strconv.FormatInt(i, 10)
-		)
+	for i := int64(0); ; i++ {
+		regName := driverName + strconv.FormatInt(i, 10)
 
-		for _, name := range sql.Drivers() {
-			if name == regName {
-				found = true
-			}
+		if !registered[regName] {
+			register(regName, dri, options)
+
+			return regName, nil
 		}
+	}
+}
 
-		if !found {
-			sql.Register(regName, Wrap(dri, options...))
+// RegisterAs registers wrapped database driver identified by its driverName with an explicit name.
+// Handle of registered driver is available with Lookup.
+//
+// Driver is resolved by driverName on first open with data source name of sql.Open,
+// so that drivers that can not be opened with an empty source are supported.
+func RegisterAs(name string, driverName string, options ...Option) error {
+	regMu.Lock()
+	defer regMu.Unlock()
 
-			return regName, nil
+	if err := checkRegistration(name); err != nil {
+		return err
+	}
+
+	if checkRegistration(driverName) == nil {
+		return fmt.Errorf("sql: unknown driver %q (forgotten import?)", driverName)
+	}
+
+	o, ok := prepareOptions(options)
+
+	sql.Register(name, &namedDriver{name: driverName, options: o, wrap: ok})
+
+	registry[name] = o.handle
+
+	return nil
+}
+
+// RegisterDriverAs registers wrapped database driver with an explicit name.
+func RegisterDriverAs(name string, d driver.Driver, options ...Option) error {
+	regMu.Lock()
+	defer regMu.Unlock()
+
+	if err := checkRegistration(name); err != nil {
+		return err
+	}
+
+	register(name, d, options)
+
+	return nil
+}
+
+// checkRegistration fails if driver name is already registered.
+func checkRegistration(name string) error {
+	for _, n := range sql.Drivers() {
+		if n == name {
+			return fmt.Errorf("driver %q is already registered", name)
+		}
+	}
+
+	return nil
+}
+
+// namedDriver wraps a driver registered with name, driver is resolved on first open.
+type namedDriver struct {
+	name    string
+	options Options
+	wrap    bool
+
+	mu     sync.Mutex
+	parent driver.Driver
+}
+
+// driver returns wrapped driver, dsn is only used to resolve it.
+func (d *namedDriver) driver(dsn string) (driver.Driver, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.parent != nil {
+		return d.parent, nil
+	}
+
+	p, err := parentDriver(d.name, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.wrap {
+		p = wrapDriver(p, d.options)
+	}
+
+	d.parent = p
+
+	return p, nil
+}
+
+// Open implements driver.Driver.
+func (d *namedDriver) Open(dsn string) (driver.Conn, error) {
+	p, err := d.driver(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Open(dsn)
+}
+
+func (d *namedDriver) handle() *Handle {
+	return d.options.handle
+}
+
+// Lookup returns Handle of a driver registered by dbwrap or nil,
+// driver without options to apply has no Handle unless it is registered WithHandle.
+func Lookup(name string) *Handle {
+	regMu.Lock()
+	defer regMu.Unlock()
+
+	return registry[name]
+}
+
+// registry keeps handles of registered drivers, access is synchronized with regMu.
+var registry = map[string]*Handle{}
+
+// register wraps and registers a driver, regMu must be locked.
+func register(name string, d driver.Driver, options []Option) {
+	w := Wrap(d, options...)
+
+	sql.Register(name, w)
+
+	registry[name] = HandleOf(w)
+}
+
+// parentDriver returns driver implementation by name.
+func parentDriver(driverName string, source string) (driver.Driver, error) {
+	// Empty source keeps driver.DriverContext from parsing source only to discard connector,
+	// source is used if driver rejects empty one.
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		if db, err = sql.Open(driverName, source); err != nil {
+			return nil, err
 		}
 	}
 
-	return "", errors.New("unable to register driver, all slots have been taken")
+	dri := db.Driver()
+
+	if err = db.Close(); err != nil {
+		return nil, err
+	}
+
+	return dri, nil
 }
 
 // Wrap takes a SQL driver and wraps it with middlewares.
@@ -144,6 +274,14 @@ func Wrap(d driver.Driver, options ...Option) driver.Driver {
 	return d
 }
 
+// WrapHandle wraps a SQL driver and returns Handle to reconfigure it at runtime,
+// driver is wrapped even if options have nothing to apply yet.
+func WrapHandle(d driver.Driver, options ...Option) (driver.Driver, *Handle) {
+	w := Wrap(d, append(append([]Option(nil), options...), WithHandle())...)
+
+	return w, HandleOf(w)
+}
+
 // Open implements driver.Driver.
 func (d wDriver) Open(name string) (driver.Conn, error) {
 	c, err := d.parent.Open(name)
@@ -166,28 +304,85 @@ func WrapConn(c driver.Conn, options ...Option) driver.Conn {
 // wConn implements driver.Conn.
 type wConn struct {
 	parent  driver.Conn
+	id      int64
 	options Options
+
+	// stmts is a cache of prepared statements, nil if disabled.
+	stmts *stmtCache
+
+	// tx is set while connection is in transaction,
+	// access is synchronized by database/sql with connection lock.
+	tx *txState
+
+	// tenant is a current schema of connection, see WithTenantRouting.
+	tenant string
+
+	// txTenant is a schema set in transaction, it becomes current on commit if txTenantSet.
+	txTenant    string
+	txTenantSet bool
+}
+
+// newConn creates a connection wrapper.
+func newConn(parent driver.Conn, options Options) *wConn {
+	c := &wConn{
+		parent:  parent,
+		id:      atomic.AddInt64(&connSeq, 1),
+		options: options,
+		stmts:   newStmtCache(options.stmtCacheSize),
+	}
+	c.options.conn = c
+
+	return c
+}
+
+// connSeq is a sequence of connection identifiers.
+var connSeq int64
+
+// connObserver receives errors of driver calls made with a connection
+// and reports whether connection should be discarded.
+type connObserver interface {
+	observe(err error)
+	isBad() bool
+}
+
+// observe passes non-nil error to connection observer, if it is available.
+func (o Options) observe(err error) {
+	if err != nil && o.observer != nil {
+		o.observer.observe(err)
+	}
 }
 
 func apply(
 	ctx context.Context,
-	mws []Middleware,
+	options Options,
+	state *handleState,
 	operation Operation,
 	statement string,
 	args []driver.NamedValue,
 ) (context.Context, []func(error)) {
-	finalizers := make([]func(error), len(mws))
-	n := len(mws)
+	middlewares, filters := state.middlewares, state.filters
+	if len(middlewares) == 0 {
+		return ctx, nil
+	}
+
+	finalizers := make([]func(error), 0, len(middlewares))
+	ctx = options.withOperation(ctx, operation, statement)
+
+	for i, mw := range middlewares {
+		if i < len(filters) && !filters[i].applies(ctx, operation, statement) {
+			continue
+		}
 
-	for i, mw := range mws {
 		newCtx, onFinish := mw(ctx, operation, statement, args)
 		ctx = newCtx
 
-		if onFinish == nil {
-			onFinish = func(err error) {}
+		if onFinish != nil {
+			finalizers = append(finalizers, onFinish)
 		}
+	}
 
-		finalizers[n-i-1] = onFinish
+	for i, j := 0, len(finalizers)-1; i < j; i, j = i+1, j-1 {
+		finalizers[i], finalizers[j] = finalizers[j], finalizers[i]
 	}
 
 	return ctx, finalizers
@@ -219,9 +414,22 @@ func values(nargs []driver.NamedValue) []driver.Value {
 	return args
 }
 
-func (c wConn) Ping(ctx context.Context) (err error) {
-	if c.options.operations[Ping] {
-		newCtx, finalizers := apply(ctx, c.options.Middlewares, Ping, "", nil)
+// hasNames checks if arguments can not be passed to legacy Execer or Queryer.
+func hasNames(nargs []driver.NamedValue) bool {
+	for _, a := range nargs {
+		if a.Name != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *wConn) Ping(ctx context.Context) (err error) {
+	state := c.options.state()
+
+	if state.enabled(Ping) {
+		newCtx, finalizers := apply(ctx, c.options, state, Ping, "", nil)
 		ctx = newCtx
 
 		defer func() {
@@ -232,14 +440,19 @@ func (c wConn) Ping(ctx context.Context) (err error) {
 	}
 
 	if pinger, ok := c.parent.(driver.Pinger); ok {
-		return pinger.Ping(ctx)
+		err = c.options.call(ctx, Ping, "", pinger.Ping)
+		c.options.observe(err)
+
+		return err
 	}
 
 	return errors.New("driver does not implement Ping")
 }
 
-func (c wConn) Exec(query string, args []driver.Value) (res driver.Result, err error) {
-	ctx := context.Background()
+func (c *wConn) Exec(query string, args []driver.Value) (res driver.Result, err error) {
+	state := c.options.state()
+
+	ctx := c.txContext(context.Background())
 
 	//nolint:staticcheck // Deprecated usage for backwards compatibility.
 	exec, ok := c.parent.(driver.Execer)
@@ -248,15 +461,29 @@ func (c wConn) Exec(query string, args []driver.Value) (res driver.Result, err e
 		return nil, driver.ErrSkip
 	}
 
-	if c.options.Intercept != nil {
-		nctx, nquery, nargs := c.options.Intercept(ctx, Exec, query, namedValues(args))
+	if err := c.routeTenant(ctx); err != nil {
+		return nil, err
+	}
+
+	if c.options.placeholders != 0 {
+		nctx, nquery, nargs, err := c.options.placeholders.rewrite(ctx, Exec, query, namedValues(args))
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, query, args = nctx, nquery, values(nargs)
+	}
+
+	if intercept := state.intercept; intercept != nil {
+		ctx = c.options.withOperation(ctx, Exec, query)
+		nctx, nquery, nargs := intercept(ctx, Exec, query, namedValues(args))
 		ctx = nctx
 		args = values(nargs)
 		query = nquery
 	}
 
-	if c.options.operations[Exec] {
-		newCtx, finalizers := apply(ctx, c.options.Middlewares, Exec, query, namedValues(args))
+	if state.enabled(Exec) {
+		newCtx, finalizers := apply(ctx, c.options, state, Exec, query, namedValues(args))
 		ctx = newCtx
 
 		defer func() {
@@ -266,26 +493,53 @@ func (c wConn) Exec(query string, args []driver.Value) (res driver.Result, err e
 		}()
 	}
 
-	if res, err = exec.Exec(query, args); err != nil {
+	done, err := c.options.check(ctx, Exec, query, namedValues(args))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { done(err) }()
+
+	res, err = c.options.exec(ctx, Exec, query, namedValues(args), func(_ context.Context) (driver.Result, error) {
+		return exec.Exec(query, args)
+	})
+	if err != nil {
+		c.options.observe(err)
+
 		return nil, err
 	}
 
 	return wResult{parent: res, ctx: ctx, options: c.options}, nil
 }
 
-func (c wConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (res driver.Result, err error) {
+func (c *wConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (res driver.Result, err error) {
+	state := c.options.state()
+
 	execCtx, ok := c.parent.(driver.ExecerContext)
 
-	if !ok {
+	if !ok && c.stmts == nil {
 		return nil, driver.ErrSkip
 	}
 
-	if c.options.Intercept != nil {
-		ctx, query, args = c.options.Intercept(ctx, Exec, query, args)
+	ctx = c.txContext(ctx)
+
+	if err := c.routeTenant(ctx); err != nil {
+		return nil, err
+	}
+
+	if c.options.placeholders != 0 {
+		if ctx, query, args, err = c.options.placeholders.rewrite(ctx, Exec, query, args); err != nil {
+			return nil, err
+		}
+	}
+
+	if intercept := state.intercept; intercept != nil {
+		ctx = c.options.withOperation(ctx, Exec, query)
+		ctx, query, args = intercept(ctx, Exec, query, args)
 	}
 
-	if c.options.operations[Exec] {
-		newCtx, finalizers := apply(ctx, c.options.Middlewares, Exec, query, args)
+	if state.enabled(Exec) {
+		newCtx, finalizers := apply(ctx, c.options, state, Exec, query, args)
 		ctx = newCtx
 
 		defer func() {
@@ -295,14 +549,41 @@ func (c wConn) ExecContext(ctx context.Context, query string, args []driver.Name
 		}()
 	}
 
-	if res, err = execCtx.ExecContext(ctx, query, args); err != nil {
+	done, err := c.options.check(ctx, Exec, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { done(err) }()
+
+	res, err = c.options.exec(ctx, Exec, query, args, func(ctx context.Context) (driver.Result, error) {
+		if ok {
+			res, err := execCtx.ExecContext(ctx, query, args)
+			if err != driver.ErrSkip || c.stmts == nil {
+				return res, err
+			}
+		} else if exec, ok := c.parent.(driver.Execer); ok && !hasNames(args) { //nolint:staticcheck // Deprecated usage for backwards compatibility.
+			// Legacy execer is tried before statement cache like database/sql does.
+			res, err := exec.Exec(query, values(args))
+			if err != driver.ErrSkip {
+				return res, err
+			}
+		}
+
+		return c.execCached(ctx, query, args)
+	})
+	if err != nil {
+		c.options.observe(err)
+
 		return nil, err
 	}
 
 	return wResult{parent: res, ctx: ctx, options: c.options}, nil
 }
 
-func (c wConn) Query(query string, args []driver.Value) (rows driver.Rows, err error) {
+func (c *wConn) Query(query string, args []driver.Value) (rows driver.Rows, err error) {
+	state := c.options.state()
+
 	//nolint:staticcheck // Deprecated usage for backwards compatibility.
 	queryer, ok := c.parent.(driver.Queryer)
 
@@ -310,17 +591,31 @@ func (c wConn) Query(query string, args []driver.Value) (rows driver.Rows, err e
 		return nil, driver.ErrSkip
 	}
 
-	ctx := context.Background()
+	ctx := c.txContext(context.Background())
+
+	if err := c.routeTenant(ctx); err != nil {
+		return nil, err
+	}
+
+	if c.options.placeholders != 0 {
+		nctx, nquery, nargs, err := c.options.placeholders.rewrite(ctx, Query, query, namedValues(args))
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, query, args = nctx, nquery, values(nargs)
+	}
 
-	if c.options.Intercept != nil {
-		nctx, nquery, nargs := c.options.Intercept(ctx, Query, query, namedValues(args))
+	if intercept := state.intercept; intercept != nil {
+		ctx = c.options.withOperation(ctx, Query, query)
+		nctx, nquery, nargs := intercept(ctx, Query, query, namedValues(args))
 		ctx = nctx
 		query = nquery
 		args = values(nargs)
 	}
 
-	if c.options.operations[Query] {
-		newCtx, finalizers := apply(ctx, c.options.Middlewares, Query, query, namedValues(args))
+	if state.enabled(Query) {
+		newCtx, finalizers := apply(ctx, c.options, state, Query, query, namedValues(args))
 		ctx = newCtx
 
 		defer func() {
@@ -330,27 +625,53 @@ func (c wConn) Query(query string, args []driver.Value) (rows driver.Rows, err e
 		}()
 	}
 
-	rows, err = queryer.Query(query, args)
+	done, err := c.options.check(ctx, Query, query, namedValues(args))
 	if err != nil {
 		return nil, err
 	}
 
+	defer func() { done(err) }()
+
+	rows, err = c.options.query(ctx, Query, query, namedValues(args), func(_ context.Context) (driver.Rows, error) {
+		return queryer.Query(query, args)
+	})
+	if err != nil {
+		c.options.observe(err)
+
+		return nil, err
+	}
+
 	return wrapRows(ctx, rows, c.options), nil
 }
 
-func (c wConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
+func (c *wConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
+	state := c.options.state()
+
 	queryerCtx, ok := c.parent.(driver.QueryerContext)
 
-	if !ok {
+	if !ok && c.stmts == nil {
 		return nil, driver.ErrSkip
 	}
 
-	if c.options.Intercept != nil {
-		ctx, query, args = c.options.Intercept(ctx, Query, query, args)
+	ctx = c.txContext(ctx)
+
+	if err := c.routeTenant(ctx); err != nil {
+		return nil, err
 	}
 
-	if c.options.operations[Query] {
-		newCtx, finalizers := apply(ctx, c.options.Middlewares, Query, query, args)
+	if c.options.placeholders != 0 {
+		if ctx, query, args, err = c.options.placeholders.rewrite(ctx, Query, query, args); err != nil {
+			return nil, err
+		}
+	}
+
+	if intercept := state.intercept; intercept != nil {
+		ctx = c.options.withOperation(ctx, Query, query)
+		ctx, query, args = intercept(ctx, Query, query, args)
+	}
+
+	if state.enabled(Query) {
+		newCtx, finalizers := apply(ctx, c.options, state, Query, query, args)
 		ctx = newCtx
 
 		defer func() {
@@ -360,23 +681,60 @@ func (c wConn) QueryContext(ctx context.Context, query string, args []driver.Nam
 		}()
 	}
 
-	rows, err = queryerCtx.QueryContext(ctx, query, args)
+	done, err := c.options.check(ctx, Query, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { done(err) }()
+
+	rows, err = c.options.query(ctx, Query, query, args, func(ctx context.Context) (driver.Rows, error) {
+		if ok {
+			rows, err := queryerCtx.QueryContext(ctx, query, args)
+			if err != driver.ErrSkip || c.stmts == nil {
+				return rows, err
+			}
+		} else if queryer, ok := c.parent.(driver.Queryer); ok && !hasNames(args) { //nolint:staticcheck // Deprecated usage for backwards compatibility.
+			// Legacy queryer is tried before statement cache like database/sql does.
+			rows, err := queryer.Query(query, values(args))
+			if err != driver.ErrSkip {
+				return rows, err
+			}
+		}
+
+		return c.queryCached(ctx, query, args)
+	})
 	if err != nil {
+		c.options.observe(err)
+
 		return nil, err
 	}
 
 	return wrapRows(ctx, rows, c.options), nil
 }
 
-func (c wConn) Prepare(query string) (stmt driver.Stmt, err error) {
-	ctx := context.Background()
+func (c *wConn) Prepare(query string) (stmt driver.Stmt, err error) {
+	state := c.options.state()
+
+	ctx := c.txContext(context.Background())
+
+	if err := c.routeTenant(ctx); err != nil {
+		return nil, err
+	}
+
+	if c.options.placeholders != 0 {
+		if ctx, query, _, err = c.options.placeholders.rewrite(ctx, Prepare, query, nil); err != nil {
+			return nil, err
+		}
+	}
 
-	if c.options.Intercept != nil {
-		ctx, query, _ = c.options.Intercept(ctx, Prepare, query, nil)
+	if intercept := state.intercept; intercept != nil {
+		ctx = c.options.withOperation(ctx, Prepare, query)
+		ctx, query, _ = intercept(ctx, Prepare, query, nil)
 	}
 
-	if c.options.operations[Prepare] {
-		newCtx, finalizers := apply(ctx, c.options.Middlewares, Prepare, query, nil)
+	if state.enabled(Prepare) {
+		newCtx, finalizers := apply(ctx, c.options, state, Prepare, query, nil)
 		ctx = newCtx
 
 		defer func() {
@@ -386,15 +744,34 @@ func (c wConn) Prepare(query string) (stmt driver.Stmt, err error) {
 		}()
 	}
 
-	stmt, err = c.parent.Prepare(query)
+	done, err := c.options.check(ctx, Prepare, query, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return wrapStmt(ctx, stmt, query, c.options), nil
+	defer func() { done(err) }()
+
+	err = c.options.call(ctx, Prepare, query, func(_ context.Context) error {
+		stmt, err = c.parent.Prepare(query)
+
+		return err
+	})
+	if err != nil {
+		c.options.observe(err)
+
+		return nil, err
+	}
+
+	return wrapStmt(ctx, c, stmt, query), nil
 }
 
 func (c *wConn) Close() error {
+	c.abandonTx()
+
+	if c.stmts != nil {
+		c.stmts.close()
+	}
+
 	return c.parent.Close()
 }
 
@@ -403,12 +780,27 @@ func (c *wConn) Begin() (driver.Tx, error) {
 }
 
 func (c *wConn) PrepareContext(ctx context.Context, query string) (stmt driver.Stmt, err error) {
-	if c.options.Intercept != nil {
-		ctx, query, _ = c.options.Intercept(ctx, Prepare, query, nil)
+	state := c.options.state()
+
+	ctx = c.txContext(ctx)
+
+	if err := c.routeTenant(ctx); err != nil {
+		return nil, err
 	}
 
-	if c.options.operations[Prepare] {
-		newCtx, finalizers := apply(ctx, c.options.Middlewares, Prepare, query, nil)
+	if c.options.placeholders != 0 {
+		if ctx, query, _, err = c.options.placeholders.rewrite(ctx, Prepare, query, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if intercept := state.intercept; intercept != nil {
+		ctx = c.options.withOperation(ctx, Prepare, query)
+		ctx, query, _ = intercept(ctx, Prepare, query, nil)
+	}
+
+	if state.enabled(Prepare) {
+		newCtx, finalizers := apply(ctx, c.options, state, Prepare, query, nil)
 		ctx = newCtx
 
 		defer func() {
@@ -418,18 +810,44 @@ func (c *wConn) PrepareContext(ctx context.Context, query string) (stmt driver.S
 		}()
 	}
 
-	if prepCtx, ok := c.parent.(driver.ConnPrepareContext); ok {
-		if stmt, err = prepCtx.PrepareContext(ctx, query); err != nil {
-			return nil, err
+	done, err := c.options.check(ctx, Prepare, query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { done(err) }()
+
+	err = c.options.call(ctx, Prepare, query, func(ctx context.Context) error {
+		if prepCtx, ok := c.parent.(driver.ConnPrepareContext); ok {
+			stmt, err = prepCtx.PrepareContext(ctx, query)
+		} else {
+			stmt, err = c.parent.Prepare(query)
 		}
+
+		return err
+	})
+	if err != nil {
+		c.options.observe(err)
+
+		return nil, err
 	}
 
-	return wrapStmt(ctx, stmt, query, c.options), nil
+	return wrapStmt(ctx, c, stmt, query), nil
 }
 
 func (c *wConn) BeginTx(ctx context.Context, opts driver.TxOptions) (tx driver.Tx, err error) {
-	if c.options.operations[Begin] {
-		newCtx, finalizers := apply(ctx, c.options.Middlewares, Begin, "", nil)
+	state := c.options.state()
+
+	if err := c.routeTenant(ctx); err != nil {
+		return nil, err
+	}
+
+	if c.options.isReadOnly(ctx) {
+		opts.ReadOnly = true
+	}
+
+	if state.enabled(Begin) {
+		newCtx, finalizers := apply(ctx, c.options, state, Begin, "", nil)
 		ctx = newCtx
 
 		defer func() {
@@ -440,20 +858,83 @@ func (c *wConn) BeginTx(ctx context.Context, opts driver.TxOptions) (tx driver.T
 	}
 
 	if connBeginTx, ok := c.parent.(driver.ConnBeginTx); ok {
-		tx, err = connBeginTx.BeginTx(ctx, opts)
+		err = c.options.call(ctx, Begin, "", func(ctx context.Context) error {
+			tx, err = connBeginTx.BeginTx(ctx, opts)
+
+			return err
+		})
 		if err != nil {
+			c.options.observe(err)
+
 			return nil, err
 		}
 
-		return wTx{parent: tx, ctx: ctx, options: c.options}, nil
+		return c.beginTx(ctx, tx, opts), nil
 	}
 
-	tx, err = c.parent.Begin() //nolint:staticcheck // Deprecated usage for backwards compatibility.
+	err = c.options.call(ctx, Begin, "", func(_ context.Context) error {
+		tx, err = c.parent.Begin() //nolint:staticcheck // Deprecated usage for backwards compatibility.
+
+		return err
+	})
 	if err != nil {
+		c.options.observe(err)
+
 		return nil, err
 	}
 
-	return wTx{parent: tx, ctx: ctx, options: c.options}, nil
+	return c.beginTx(ctx, tx, opts), nil
+}
+
+// beginTx marks connection as being in transaction.
+func (c *wConn) beginTx(ctx context.Context, tx driver.Tx, opts driver.TxOptions) wTx {
+	c.tx = &txState{id: atomic.AddInt64(&txSeq, 1), readOnly: opts.ReadOnly}
+
+	return wTx{parent: tx, ctx: context.WithValue(ctx, txCtxKey{}, c.tx), options: c.options, conn: c}
+}
+
+// txContext marks context of an operation that is executed in transaction.
+func (c *wConn) txContext(ctx context.Context) context.Context {
+	if c != nil && c.tx != nil {
+		return context.WithValue(ctx, txCtxKey{}, c.tx)
+	}
+
+	return ctx
+}
+
+// abandonTx drops transaction that ends without Commit or Rollback, for example on closed or reset connection.
+func (c *wConn) abandonTx() {
+	if c.tx == nil {
+		return
+	}
+
+	c.options.abandonTx(c.tx)
+	c.tx = nil
+}
+
+// txSeq is a sequence of transaction identifiers.
+var txSeq int64
+
+// txState identifies a transaction of connection.
+type txState struct {
+	id       int64
+	readOnly bool
+}
+
+type txCtxKey struct{}
+
+// txFromContext returns transaction of operation or nil.
+func txFromContext(ctx context.Context) *txState {
+	tx, _ := ctx.Value(txCtxKey{}).(*txState)
+
+	return tx
+}
+
+// InTx returns true if context belongs to an operation that is executed in transaction.
+//
+// Begin operation is not in transaction, Commit and Rollback operations are.
+func InTx(ctx context.Context) bool {
+	return txFromContext(ctx) != nil
 }
 
 func (c *wConn) CheckNamedValue(nv *driver.NamedValue) (err error) {
@@ -475,8 +956,10 @@ type wResult struct {
 }
 
 func (r wResult) LastInsertId() (id int64, err error) {
-	if r.options.operations[LastInsertID] {
-		_, finalizers := apply(r.ctx, r.options.Middlewares, LastInsertID, "", nil)
+	state := r.options.state()
+
+	if state.enabled(LastInsertID) {
+		_, finalizers := apply(r.ctx, r.options, state, LastInsertID, "", nil)
 
 		defer func() {
 			for _, onFinish := range finalizers {
@@ -491,8 +974,10 @@ func (r wResult) LastInsertId() (id int64, err error) {
 }
 
 func (r wResult) RowsAffected() (cnt int64, err error) {
-	if r.options.operations[RowsAffected] {
-		_, finalizers := apply(r.ctx, r.options.Middlewares, RowsAffected, "", nil)
+	state := r.options.state()
+
+	if state.enabled(RowsAffected) {
+		_, finalizers := apply(r.ctx, r.options, state, RowsAffected, "", nil)
 
 		defer func() {
 			for _, onFinish := range finalizers {
@@ -507,20 +992,35 @@ func (r wResult) RowsAffected() (cnt int64, err error) {
 // wStmt implements driver.Stmt.
 type wStmt struct {
 	ctx     context.Context
+	conn    *wConn
 	parent  driver.Stmt
 	query   string
 	options Options
 }
 
 func (s wStmt) Exec(args []driver.Value) (res driver.Result, err error) {
-	if s.options.Intercept != nil {
-		ctx, _, nargs := s.options.Intercept(s.ctx, StmtExec, s.query, namedValues(args))
+	state := s.options.state()
+
+	s.ctx = s.conn.txContext(s.ctx)
+
+	if s.options.placeholders != 0 {
+		nargs, err := s.options.placeholders.stmtArgs(s.ctx, namedValues(args))
+		if err != nil {
+			return nil, err
+		}
+
+		args = values(nargs)
+	}
+
+	if intercept := state.intercept; intercept != nil {
+		s.ctx = s.options.withOperation(s.ctx, StmtExec, s.query)
+		ctx, _, nargs := intercept(s.ctx, StmtExec, s.query, namedValues(args))
 		s.ctx = ctx
 		args = values(nargs)
 	}
 
-	if s.options.operations[StmtExec] {
-		newCtx, finalizers := apply(s.ctx, s.options.Middlewares, StmtExec, s.query, namedValues(args))
+	if state.enabled(StmtExec) {
+		newCtx, finalizers := apply(s.ctx, s.options, state, StmtExec, s.query, namedValues(args))
 		s.ctx = newCtx
 
 		defer func() {
@@ -530,8 +1030,12 @@ func (s wStmt) Exec(args []driver.Value) (res driver.Result, err error) {
 		}()
 	}
 
-	res, err = s.parent.Exec(args) //nolint:staticcheck // Deprecated usage for backwards compatibility.
+	res, err = s.options.exec(s.ctx, StmtExec, s.query, namedValues(args), func(_ context.Context) (driver.Result, error) {
+		return s.parent.Exec(args) //nolint:staticcheck // Deprecated usage for backwards compatibility.
+	})
 	if err != nil {
+		s.options.observe(err)
+
 		return nil, err
 	}
 
@@ -539,8 +1043,10 @@ func (s wStmt) Exec(args []driver.Value) (res driver.Result, err error) {
 }
 
 func (s wStmt) Close() (err error) {
-	if s.options.operations[StmtClose] {
-		_, finalizers := apply(s.ctx, s.options.Middlewares, StmtClose, s.query, nil)
+	state := s.options.state()
+
+	if state.enabled(StmtClose) {
+		_, finalizers := apply(s.ctx, s.options, state, StmtClose, s.query, nil)
 
 		defer func() {
 			for _, onFinish := range finalizers {
@@ -560,18 +1066,37 @@ func (s wStmt) NumInput() int {
 		return -1
 	}
 
+	// Rewritten placeholders may differ in number from arguments.
+	if s.options.placeholders != 0 {
+		return -1
+	}
+
 	return s.parent.NumInput()
 }
 
 func (s wStmt) Query(args []driver.Value) (rows driver.Rows, err error) {
-	if s.options.Intercept != nil {
-		ctx, _, nargs := s.options.Intercept(s.ctx, StmtQuery, s.query, namedValues(args))
+	state := s.options.state()
+
+	s.ctx = s.conn.txContext(s.ctx)
+
+	if s.options.placeholders != 0 {
+		nargs, err := s.options.placeholders.stmtArgs(s.ctx, namedValues(args))
+		if err != nil {
+			return nil, err
+		}
+
+		args = values(nargs)
+	}
+
+	if intercept := state.intercept; intercept != nil {
+		s.ctx = s.options.withOperation(s.ctx, StmtQuery, s.query)
+		ctx, _, nargs := intercept(s.ctx, StmtQuery, s.query, namedValues(args))
 		s.ctx = ctx
 		args = values(nargs)
 	}
 
-	if s.options.operations[StmtQuery] {
-		newCtx, finalizers := apply(s.ctx, s.options.Middlewares, StmtQuery, s.query, namedValues(args))
+	if state.enabled(StmtQuery) {
+		newCtx, finalizers := apply(s.ctx, s.options, state, StmtQuery, s.query, namedValues(args))
 		s.ctx = newCtx
 
 		defer func() {
@@ -581,8 +1106,12 @@ func (s wStmt) Query(args []driver.Value) (rows driver.Rows, err error) {
 		}()
 	}
 
-	rows, err = s.parent.Query(args) //nolint:staticcheck // Deprecated usage for backwards compatibility.
+	rows, err = s.options.query(s.ctx, StmtQuery, s.query, namedValues(args), func(_ context.Context) (driver.Rows, error) {
+		return s.parent.Query(args) //nolint:staticcheck // Deprecated usage for backwards compatibility.
+	})
 	if err != nil {
+		s.options.observe(err)
+
 		return nil, err
 	}
 
@@ -590,12 +1119,23 @@ func (s wStmt) Query(args []driver.Value) (rows driver.Rows, err error) {
 }
 
 func (s wStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (res driver.Result, err error) {
-	if s.options.Intercept != nil {
-		ctx, _, args = s.options.Intercept(s.ctx, StmtExec, s.query, args)
+	state := s.options.state()
+
+	ctx = s.conn.txContext(ctx)
+
+	if s.options.placeholders != 0 {
+		if args, err = s.options.placeholders.stmtArgs(s.ctx, args); err != nil {
+			return nil, err
+		}
+	}
+
+	if intercept := state.intercept; intercept != nil {
+		ctx = s.options.withOperation(ctx, StmtExec, s.query)
+		ctx, _, args = intercept(ctx, StmtExec, s.query, args)
 	}
 
-	if s.options.operations[StmtExec] {
-		newCtx, finalizers := apply(ctx, s.options.Middlewares, StmtExec, s.query, args)
+	if state.enabled(StmtExec) {
+		newCtx, finalizers := apply(ctx, s.options, state, StmtExec, s.query, args)
 		ctx = newCtx
 
 		defer func() {
@@ -610,8 +1150,12 @@ func (s wStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (res d
 		return nil, errors.New("driver does not implement ExecContext")
 	}
 
-	res, err = execContext.ExecContext(ctx, args)
+	res, err = s.options.exec(ctx, StmtExec, s.query, args, func(ctx context.Context) (driver.Result, error) {
+		return execContext.ExecContext(ctx, args)
+	})
 	if err != nil {
+		s.options.observe(err)
+
 		return nil, err
 	}
 
@@ -619,12 +1163,23 @@ func (s wStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (res d
 }
 
 func (s wStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (rows driver.Rows, err error) {
-	if s.options.Intercept != nil {
-		ctx, _, args = s.options.Intercept(ctx, StmtQuery, s.query, args)
+	state := s.options.state()
+
+	ctx = s.conn.txContext(ctx)
+
+	if s.options.placeholders != 0 {
+		if args, err = s.options.placeholders.stmtArgs(s.ctx, args); err != nil {
+			return nil, err
+		}
 	}
 
-	if s.options.operations[StmtQuery] {
-		newCtx, finalizers := apply(ctx, s.options.Middlewares, StmtQuery, s.query, args)
+	if intercept := state.intercept; intercept != nil {
+		ctx = s.options.withOperation(ctx, StmtQuery, s.query)
+		ctx, _, args = intercept(ctx, StmtQuery, s.query, args)
+	}
+
+	if state.enabled(StmtQuery) {
+		newCtx, finalizers := apply(ctx, s.options, state, StmtQuery, s.query, args)
 		ctx = newCtx
 
 		defer func() {
@@ -641,104 +1196,41 @@ func (s wStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (rows
 		}
 	}
 
-	rows, err = queryContext.QueryContext(ctx, args)
+	rows, err = s.options.query(ctx, StmtQuery, s.query, args, func(ctx context.Context) (driver.Rows, error) {
+		return queryContext.QueryContext(ctx, args)
+	})
 	if err != nil {
+		s.options.observe(err)
+
 		return nil, err
 	}
 
 	return wrapRows(ctx, rows, s.options), nil
 }
 
-// withRowsColumnTypeScanType is the same as the driver.RowsColumnTypeScanType
-// interface except it omits the driver.Rows embedded interface.
-// If the original driver.Rows wrapped implementation supports
-// RowsColumnTypeScanType we enable the original method implementation in the
-// returned driver.Rows from wrapRows by doing a composition with wRows.
-type withRowsColumnTypeScanType interface {
-	ColumnTypeScanType(index int) reflect.Type
+// withColumnConverter is the same as the driver.ColumnConverter interface,
+// but it can be embedded, because embedded driver.ColumnConverter field
+// shadows its own method ColumnConverter.
+type withColumnConverter interface {
+	ColumnConverter(idx int) driver.ValueConverter
 }
 
-// wRows implements driver.Rows and all enhancement interfaces except
-// driver.RowsColumnTypeScanType.
+// wRows implements driver.Rows, optional interfaces of parent are added with composeRows.
 type wRows struct {
 	ctx     context.Context
 	parent  driver.Rows
 	options Options
 }
 
-// HasNextResultSet calls the implements the driver.RowsNextResultSet for wRows.
-// It returns the the underlying result of HasNextResultSet from the wRows.parent
-// if the parent implements driver.RowsNextResultSet.
-func (r wRows) HasNextResultSet() bool {
-	if v, ok := r.parent.(driver.RowsNextResultSet); ok {
-		return v.HasNextResultSet()
-	}
-
-	return false
-}
-
-// NextResultsSet calls the implements the driver.RowsNextResultSet for wRows.
-// It returns the the underlying result of NextResultSet from the wRows.parent
-// if the parent implements driver.RowsNextResultSet.
-func (r wRows) NextResultSet() error {
-	if v, ok := r.parent.(driver.RowsNextResultSet); ok {
-		return v.NextResultSet()
-	}
-
-	return io.EOF
-}
-
-// ColumnTypeDatabaseTypeName calls the implements the driver.RowsColumnTypeDatabaseTypeName for wRows.
-// It returns the the underlying result of ColumnTypeDatabaseTypeName from the wRows.parent
-// if the parent implements driver.RowsColumnTypeDatabaseTypeName.
-func (r wRows) ColumnTypeDatabaseTypeName(index int) string {
-	if v, ok := r.parent.(driver.RowsColumnTypeDatabaseTypeName); ok {
-		return v.ColumnTypeDatabaseTypeName(index)
-	}
-
-	return ""
-}
-
-// ColumnTypeLength calls the implements the driver.RowsColumnTypeLength for wRows.
-// It returns the the underlying result of ColumnTypeLength from the wRows.parent
-// if the parent implements driver.RowsColumnTypeLength.
-func (r wRows) ColumnTypeLength(index int) (length int64, ok bool) {
-	if v, ok := r.parent.(driver.RowsColumnTypeLength); ok {
-		return v.ColumnTypeLength(index)
-	}
-
-	return 0, false
-}
-
-// ColumnTypeNullable calls the implements the driver.RowsColumnTypeNullable for wRows.
-// It returns the the underlying result of ColumnTypeNullable from the wRows.parent
-// if the parent implements driver.RowsColumnTypeNullable.
-func (r wRows) ColumnTypeNullable(index int) (nullable, ok bool) {
-	if v, ok := r.parent.(driver.RowsColumnTypeNullable); ok {
-		return v.ColumnTypeNullable(index)
-	}
-
-	return false, false
-}
-
-// ColumnTypePrecisionScale calls the implements the driver.RowsColumnTypePrecisionScale for wRows.
-// It returns the the underlying result of ColumnTypePrecisionScale from the wRows.parent
-// if the parent implements driver.RowsColumnTypePrecisionScale.
-func (r wRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
-	if v, ok := r.parent.(driver.RowsColumnTypePrecisionScale); ok {
-		return v.ColumnTypePrecisionScale(index)
-	}
-
-	return 0, 0, false
-}
-
 func (r wRows) Columns() []string {
 	return r.parent.Columns()
 }
 
 func (r wRows) Close() (err error) {
-	if r.options.operations[RowsClose] {
-		_, finalizers := apply(r.ctx, r.options.Middlewares, RowsClose, "", nil)
+	state := r.options.state()
+
+	if state.enabled(RowsClose) {
+		_, finalizers := apply(r.ctx, r.options, state, RowsClose, "", nil)
 
 		defer func() {
 			for _, onFinish := range finalizers {
@@ -751,8 +1243,10 @@ func (r wRows) Close() (err error) {
 }
 
 func (r wRows) Next(dest []driver.Value) (err error) {
-	if r.options.operations[RowsNext] {
-		_, finalizers := apply(r.ctx, r.options.Middlewares, RowsNext, "", nil)
+	state := r.options.state()
+
+	if state.enabled(RowsNext) {
+		_, finalizers := apply(r.ctx, r.options, state, RowsNext, "", nil)
 
 		defer func() {
 			for _, onFinish := range finalizers {
@@ -761,32 +1255,17 @@ func (r wRows) Next(dest []driver.Value) (err error) {
 		}()
 	}
 
-	return r.parent.Next(dest)
-}
-
-// wrapRows returns a struct which conforms to the driver.Rows interface.
-// wRows implements all enhancement interfaces that have no effect on
-// sql/database logic in case the underlying parent implementation lacks them.
-// Currently the one exception is RowsColumnTypeScanType which does not have a
-// valid zero value. This interface is tested for and only enabled in case the
-// parent implementation supports it.
-func wrapRows(ctx context.Context, parent driver.Rows, options Options) driver.Rows {
-	ts, hasColumnTypeScan := parent.(driver.RowsColumnTypeScanType)
-
-	r := wRows{
-		parent:  parent,
-		ctx:     ctx,
-		options: options,
+	err = r.parent.Next(dest)
+	if err != io.EOF {
+		r.options.observe(err)
 	}
 
-	if hasColumnTypeScan {
-		return struct {
-			wRows
-			withRowsColumnTypeScanType
-		}{r, ts}
-	}
+	return err
+}
 
-	return r
+// wrapRows returns driver.Rows that implements optional interfaces of parent.
+func wrapRows(ctx context.Context, parent driver.Rows, options Options) driver.Rows {
+	return composeRows(wRows{parent: parent, ctx: ctx, options: options}, parent)
 }
 
 // wTx implements driver.Tx.
@@ -794,11 +1273,14 @@ type wTx struct {
 	parent  driver.Tx
 	ctx     context.Context
 	options Options
+	conn    *wConn
 }
 
 func (t wTx) Commit() (err error) {
-	if t.options.operations[Commit] {
-		_, finalizers := apply(t.ctx, t.options.Middlewares, Commit, "", nil)
+	state := t.options.state()
+
+	if state.enabled(Commit) {
+		_, finalizers := apply(t.ctx, t.options, state, Commit, "", nil)
 
 		defer func() {
 			for _, onFinish := range finalizers {
@@ -807,12 +1289,30 @@ func (t wTx) Commit() (err error) {
 		}()
 	}
 
-	return t.parent.Commit()
+	called := false
+	err = t.options.call(t.ctx, Commit, "", func(_ context.Context) error {
+		called = true
+
+		return t.parent.Commit()
+	})
+
+	// Hook that fails without driver call must not leave transaction open on pooled connection.
+	if !called {
+		_ = t.parent.Rollback() //nolint:errcheck // Error of hook is more important.
+	}
+
+	t.conn.endTxTenant(true, err)
+	t.conn.tx = nil
+	t.options.observe(err)
+
+	return err
 }
 
 func (t wTx) Rollback() (err error) {
-	if t.options.operations[Rollback] {
-		_, finalizers := apply(t.ctx, t.options.Middlewares, Rollback, "", nil)
+	state := t.options.state()
+
+	if state.enabled(Rollback) {
+		_, finalizers := apply(t.ctx, t.options, state, Rollback, "", nil)
 
 		defer func() {
 			for _, onFinish := range finalizers {
@@ -821,5 +1321,21 @@ func (t wTx) Rollback() (err error) {
 		}()
 	}
 
-	return t.parent.Rollback()
+	called := false
+	err = t.options.call(t.ctx, Rollback, "", func(_ context.Context) error {
+		called = true
+
+		return t.parent.Rollback()
+	})
+
+	// Hook that fails without driver call must not leave transaction open on pooled connection.
+	if !called {
+		_ = t.parent.Rollback() //nolint:errcheck // Error of hook is more important.
+	}
+
+	t.conn.endTxTenant(false, err)
+	t.conn.tx = nil
+	t.options.observe(err)
+
+	return err
 }
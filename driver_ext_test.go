@@ -5,11 +5,13 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -255,3 +257,157 @@ mw1 done`
 
 	assert.Equal(t, expectedLog, strings.Join(l, "\n"))
 }
+
+func TestRegisterAs(t *testing.T) {
+	require.NoError(t, dbwrap.RegisterAs("sqlmock-named", "sqlmock", dbwrap.WithHandle()))
+	assert.EqualError(t, dbwrap.RegisterAs("sqlmock-named", "sqlmock"), `driver "sqlmock-named" is already registered`)
+	assert.Error(t, dbwrap.RegisterAs("sqlmock-unknown", "unknown"))
+	assert.NotNil(t, dbwrap.Lookup("sqlmock-named"))
+	assert.Nil(t, dbwrap.Lookup("sqlmock-unknown"))
+
+	// Driver is not wrapped without options.
+	driverName, err := dbwrap.Register("sqlmock")
+	require.NoError(t, err)
+	assert.Nil(t, dbwrap.Lookup(driverName))
+
+	db, err := sql.Open(driverName, "")
+	require.NoError(t, err)
+	assert.Equal(t, "*sqlmock.mockDriver", reflect.TypeOf(db.Driver()).String())
+	require.NoError(t, db.Close())
+
+	var seen []string
+
+	f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	require.NoError(t, dbwrap.RegisterDriverAs("fake-named", f.Connector().Driver(), dbwrap.WithHandle()))
+
+	h := dbwrap.Lookup("fake-named")
+	require.NotNil(t, h)
+
+	h.Update(dbwrap.WithMiddleware(func(
+		ctx context.Context,
+		operation dbwrap.Operation,
+		statement string,
+		args []driver.NamedValue,
+	) (context.Context, func(error)) {
+		seen = append(seen, statement)
+
+		return ctx, nil
+	}))
+
+	db, err = sql.Open("fake-named", "")
+	require.NoError(t, err)
+
+	_, err = db.Exec("DELETE FROM t")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	assert.Equal(t, []string{"DELETE FROM t"}, seen)
+}
+
+// dsnDriver can not open connector with an empty source.
+type dsnDriver struct {
+	driver.Driver
+	f *dbwraptest.Fake
+}
+
+func (d dsnDriver) OpenConnector(name string) (driver.Connector, error) {
+	if name == "" {
+		return nil, errors.New("empty source")
+	}
+
+	return d.f.Connector(), nil
+}
+
+func TestRegisterAs_dsnRequired(t *testing.T) {
+	var seen []string
+
+	f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	sql.Register("fake-dsn", dsnDriver{Driver: f.Driver(), f: f})
+
+	_, err := sql.Open("fake-dsn", "")
+	require.Error(t, err)
+
+	require.NoError(t, dbwrap.RegisterAs("fake-dsn-named", "fake-dsn", dbwrap.WithMiddleware(func(
+		ctx context.Context,
+		operation dbwrap.Operation,
+		statement string,
+		args []driver.NamedValue,
+	) (context.Context, func(error)) {
+		seen = append(seen, statement)
+
+		return ctx, nil
+	})))
+	require.NotNil(t, dbwrap.Lookup("fake-dsn-named"))
+
+	db, err := sql.Open("fake-dsn-named", "dsn")
+	require.NoError(t, err)
+
+	_, err = db.Exec("DELETE FROM t")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	assert.Equal(t, []string{"DELETE FROM t"}, seen)
+	assert.Equal(t, dbwrap.Lookup("fake-dsn-named"), dbwrap.HandleOf(db.Driver()))
+}
+
+func TestOpenDB(t *testing.T) {
+	_, mock, err := sqlmock.NewWithDSN("mocked-open-db")
+	require.NoError(t, err)
+
+	mock.ExpectExec("DELETE FROM t").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectClose()
+
+	var seen []string
+
+	db, err := dbwrap.OpenDB("sqlmock", "mocked-open-db", dbwrap.WithMiddleware(func(
+		ctx context.Context,
+		operation dbwrap.Operation,
+		statement string,
+		args []driver.NamedValue,
+	) (context.Context, func(error)) {
+		seen = append(seen, string(operation)+" "+statement)
+
+		return ctx, nil
+	}))
+	require.NoError(t, err)
+
+	_, err = db.Exec("DELETE FROM t")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, []string{"exec DELETE FROM t"}, seen)
+
+	_, err = dbwrap.OpenDB("unknown", "")
+	assert.Error(t, err)
+}
+
+func TestOpenDB_driverContext(t *testing.T) {
+	f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	sql.Register("fake-open-db", f.Connector().Driver())
+
+	db, err := dbwrap.OpenDB("fake-open-db", "dsn", dbwrap.WithHandle())
+	require.NoError(t, err)
+
+	_, err = db.Exec("DELETE FROM t")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	var (
+		methods []string
+		parsed  int
+	)
+
+	for _, c := range f.Calls() {
+		methods = append(methods, c.Method)
+
+		if c.Method == "Driver.OpenConnector" && c.Statement == "dsn" {
+			parsed++
+		}
+	}
+
+	// Connector is opened with DSN once.
+	assert.Equal(t, 1, parsed)
+	assert.Contains(t, methods, "Connector.Connect")
+	assert.NotContains(t, methods, "Driver.Open")
+}
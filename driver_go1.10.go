@@ -5,6 +5,7 @@ package dbwrap
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 )
 
@@ -14,6 +15,8 @@ var (
 	_ driver.Connector     = &wDriver{}
 )
 
+//go:generate go run ./internal/composegen -target dbwrap -out compose_gen.go
+
 // WrapConnector allows wrapping a database driver.Connector which eliminates
 // the need to register wrap as an available driver.Driver.
 func WrapConnector(dc driver.Connector, options ...Option) driver.Connector {
@@ -28,6 +31,14 @@ func WrapConnector(dc driver.Connector, options ...Option) driver.Connector {
 	return dc
 }
 
+// WrapConnectorHandle wraps a database driver.Connector and returns Handle to reconfigure it at runtime,
+// connector is wrapped even if options have nothing to apply yet.
+func WrapConnectorHandle(dc driver.Connector, options ...Option) (driver.Connector, *Handle) {
+	w := WrapConnector(dc, append(append([]Option(nil), options...), WithHandle())...)
+
+	return w, HandleOf(w)
+}
+
 // wDriver implements driver.Driver.
 type wDriver struct {
 	parent    driver.Driver
@@ -44,149 +55,20 @@ func wrapDriver(d driver.Driver, o Options) driver.Driver {
 }
 
 func wrapConn(parent driver.Conn, options Options) driver.Conn {
-	var (
-		n, hasNameValueChecker = parent.(driver.NamedValueChecker)
-		s, hasSessionResetter  = parent.(driver.SessionResetter)
-	)
-
-	c := &wConn{parent: parent, options: options}
-
-	switch {
-	case !hasNameValueChecker && !hasSessionResetter:
-		return c
-	case hasNameValueChecker && !hasSessionResetter:
-		return struct {
-			conn
-			driver.NamedValueChecker
-		}{c, n}
-	case !hasNameValueChecker && hasSessionResetter:
-		return struct {
-			conn
-			driver.SessionResetter
-		}{c, s}
-	case hasNameValueChecker && hasSessionResetter:
-		return struct {
-			conn
-			driver.NamedValueChecker
-			driver.SessionResetter
-		}{c, n, s}
-	}
+	return composeConn(newConn(parent, options), parent)
+}
 
-	panic("unreachable")
-}
-
-//nolint:funlen,gocyclo // Large switch is necessary to combine a variety of traits.
-func wrapStmt(ctx context.Context, stmt driver.Stmt, query string, options Options) driver.Stmt {
-	var (
-		_, hasExeCtx    = stmt.(driver.StmtExecContext)
-		_, hasQryCtx    = stmt.(driver.StmtQueryContext)
-		c, hasColConv   = stmt.(driver.ColumnConverter) //nolint:staticcheck // Deprecated usage for backwards compatibility.
-		n, hasNamValChk = stmt.(driver.NamedValueChecker)
-	)
-
-	s := wStmt{ctx: ctx, parent: stmt, query: query, options: options}
-
-	switch {
-	case !hasExeCtx && !hasQryCtx && !hasColConv && !hasNamValChk:
-		return struct {
-			driver.Stmt
-		}{s}
-	case !hasExeCtx && hasQryCtx && !hasColConv && !hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.StmtQueryContext
-		}{s, s}
-	case hasExeCtx && !hasQryCtx && !hasColConv && !hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.StmtExecContext
-		}{s, s}
-	case hasExeCtx && hasQryCtx && !hasColConv && !hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.StmtExecContext
-			driver.StmtQueryContext
-		}{s, s, s}
-	case !hasExeCtx && !hasQryCtx && hasColConv && !hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.ColumnConverter
-		}{s, c}
-	case !hasExeCtx && hasQryCtx && hasColConv && !hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.StmtQueryContext
-			driver.ColumnConverter
-		}{s, s, c}
-	case hasExeCtx && !hasQryCtx && hasColConv && !hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.StmtExecContext
-			driver.ColumnConverter
-		}{s, s, c}
-	case hasExeCtx && hasQryCtx && hasColConv && !hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.StmtExecContext
-			driver.StmtQueryContext
-			driver.ColumnConverter
-		}{s, s, s, c}
-
-	case !hasExeCtx && !hasQryCtx && !hasColConv && hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.NamedValueChecker
-		}{s, n}
-	case !hasExeCtx && hasQryCtx && !hasColConv && hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.StmtQueryContext
-			driver.NamedValueChecker
-		}{s, s, n}
-	case hasExeCtx && !hasQryCtx && !hasColConv && hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.StmtExecContext
-			driver.NamedValueChecker
-		}{s, s, n}
-	case hasExeCtx && hasQryCtx && !hasColConv && hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.StmtExecContext
-			driver.StmtQueryContext
-			driver.NamedValueChecker
-		}{s, s, s, n}
-	case !hasExeCtx && !hasQryCtx && hasColConv && hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.ColumnConverter
-			driver.NamedValueChecker
-		}{s, c, n}
-	case !hasExeCtx && hasQryCtx && hasColConv && hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.StmtQueryContext
-			driver.ColumnConverter
-			driver.NamedValueChecker
-		}{s, s, c, n}
-	case hasExeCtx && !hasQryCtx && hasColConv && hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.StmtExecContext
-			driver.ColumnConverter
-			driver.NamedValueChecker
-		}{s, s, c, n}
-	case hasExeCtx && hasQryCtx && hasColConv && hasNamValChk:
-		return struct {
-			driver.Stmt
-			driver.StmtExecContext
-			driver.StmtQueryContext
-			driver.ColumnConverter
-			driver.NamedValueChecker
-		}{s, s, s, c, n}
-	}
+// resetsSession checks if wrapped connection should implement driver.SessionResetter,
+// observed connection reports its health with session reset and tenant connection is switched to default tenant,
+// wConn delegates to parent session resetter if it is available.
+func resetsSession(parent driver.Conn, options Options) bool {
+	_, ok := parent.(driver.SessionResetter)
+
+	return ok || options.observer != nil || options.tenants != nil
+}
 
-	panic("unreachable")
+func wrapStmt(ctx context.Context, conn *wConn, stmt driver.Stmt, query string) driver.Stmt {
+	return composeStmt(wStmt{ctx: ctx, conn: conn, parent: stmt, query: query, options: conn.options}, stmt)
 }
 
 func (d wDriver) OpenConnector(name string) (driver.Connector, error) {
@@ -206,9 +88,78 @@ func (d wDriver) Connect(ctx context.Context) (driver.Conn, error) {
 		return nil, err
 	}
 
-	return &wConn{parent: c, options: d.options}, nil
+	return wrapConn(c, d.options), nil
 }
 
 func (d wDriver) Driver() driver.Driver {
 	return d
 }
+
+// ResetSession implements driver.SessionResetter.
+func (c *wConn) ResetSession(ctx context.Context) error {
+	c.abandonTx()
+
+	if c.options.observer != nil && c.options.observer.isBad() {
+		return driver.ErrBadConn
+	}
+
+	if s, ok := c.parent.(driver.SessionResetter); ok {
+		if err := s.ResetSession(ctx); err != nil {
+			return err
+		}
+	}
+
+	if c.tenant != "" {
+		return c.resetTenant(ctx)
+	}
+
+	return nil
+}
+
+// OpenConnector implements driver.DriverContext.
+func (d *namedDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	p, err := d.driver(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if dc, ok := p.(driver.DriverContext); ok {
+		return dc.OpenConnector(dsn)
+	}
+
+	return dsnConnector{dsn: dsn, driver: p}, nil
+}
+
+// OpenDB opens a database of a registered driver with DSN and wraps it without registration of a new driver.
+//
+// Driver connector is used if driver implements driver.DriverContext, otherwise connections are opened with DSN.
+func OpenDB(driverName, dsn string, options ...Option) (*sql.DB, error) {
+	d, err := parentDriver(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	var c driver.Connector = dsnConnector{dsn: dsn, driver: d}
+
+	if dc, ok := d.(driver.DriverContext); ok {
+		if c, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	return sql.OpenDB(WrapConnector(c, options...)), nil
+}
+
+// dsnConnector implements driver.Connector for drivers without driver.DriverContext.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
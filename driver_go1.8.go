@@ -23,11 +23,11 @@ func wrapDriver(d driver.Driver, o Options) driver.Driver {
 }
 
 func wrapConn(c driver.Conn, options Options) driver.Conn {
-	return &wConn{parent: c, options: options}
+	return newConn(c, options)
 }
 
-func wrapStmt(ctx context.Context, stmt driver.Stmt, query string, options Options) driver.Stmt {
-	s := wStmt{ctx: ctx, parent: stmt, query: query, options: options}
+func wrapStmt(ctx context.Context, conn *wConn, stmt driver.Stmt, query string) driver.Stmt {
+	s := wStmt{ctx: ctx, conn: conn, parent: stmt, query: query, options: conn.options}
 	_, hasExeCtx := stmt.(driver.StmtExecContext)
 	_, hasQryCtx := stmt.(driver.StmtQueryContext)
 	c, hasColCnv := stmt.(driver.ColumnConverter)
@@ -55,26 +55,26 @@ func wrapStmt(ctx context.Context, stmt driver.Stmt, query string, options Optio
 	case !hasExeCtx && !hasQryCtx && hasColCnv:
 		return struct {
 			driver.Stmt
-			driver.ColumnConverter
+			withColumnConverter
 		}{s, c}
 	case !hasExeCtx && hasQryCtx && hasColCnv:
 		return struct {
 			driver.Stmt
 			driver.StmtQueryContext
-			driver.ColumnConverter
+			withColumnConverter
 		}{s, s, c}
 	case hasExeCtx && !hasQryCtx && hasColCnv:
 		return struct {
 			driver.Stmt
 			driver.StmtExecContext
-			driver.ColumnConverter
+			withColumnConverter
 		}{s, s, c}
 	case hasExeCtx && hasQryCtx && hasColCnv:
 		return struct {
 			driver.Stmt
 			driver.StmtExecContext
 			driver.StmtQueryContext
-			driver.ColumnConverter
+			withColumnConverter
 		}{s, s, s, c}
 	}
 	panic("unreachable")
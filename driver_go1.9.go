@@ -23,7 +23,7 @@ func wrapDriver(d driver.Driver, o Options) driver.Driver {
 
 func wrapConn(parent driver.Conn, options Options) driver.Conn {
 	n, hasNameValueChecker := parent.(driver.NamedValueChecker)
-	c := &wConn{parent: parent, options: options}
+	c := newConn(parent, options)
 	if hasNameValueChecker {
 		return struct {
 			conn
@@ -33,7 +33,7 @@ func wrapConn(parent driver.Conn, options Options) driver.Conn {
 	return c
 }
 
-func wrapStmt(ctx context.Context, stmt driver.Stmt, query string, options Options) driver.Stmt {
+func wrapStmt(ctx context.Context, conn *wConn, stmt driver.Stmt, query string) driver.Stmt {
 	var (
 		_, hasExeCtx    = stmt.(driver.StmtExecContext)
 		_, hasQryCtx    = stmt.(driver.StmtQueryContext)
@@ -41,7 +41,7 @@ func wrapStmt(ctx context.Context, stmt driver.Stmt, query string, options Optio
 		n, hasNamValChk = stmt.(driver.NamedValueChecker)
 	)
 
-	s := wStmt{ctx: ctx, parent: stmt, query: query, options: options}
+	s := wStmt{ctx: ctx, conn: conn, parent: stmt, query: query, options: conn.options}
 	switch {
 	case !hasExeCtx && !hasQryCtx && !hasColConv && !hasNamValChk:
 		return struct {
@@ -66,26 +66,26 @@ func wrapStmt(ctx context.Context, stmt driver.Stmt, query string, options Optio
 	case !hasExeCtx && !hasQryCtx && hasColConv && !hasNamValChk:
 		return struct {
 			driver.Stmt
-			driver.ColumnConverter
+			withColumnConverter
 		}{s, c}
 	case !hasExeCtx && hasQryCtx && hasColConv && !hasNamValChk:
 		return struct {
 			driver.Stmt
 			driver.StmtQueryContext
-			driver.ColumnConverter
+			withColumnConverter
 		}{s, s, c}
 	case hasExeCtx && !hasQryCtx && hasColConv && !hasNamValChk:
 		return struct {
 			driver.Stmt
 			driver.StmtExecContext
-			driver.ColumnConverter
+			withColumnConverter
 		}{s, s, c}
 	case hasExeCtx && hasQryCtx && hasColConv && !hasNamValChk:
 		return struct {
 			driver.Stmt
 			driver.StmtExecContext
 			driver.StmtQueryContext
-			driver.ColumnConverter
+			withColumnConverter
 		}{s, s, s, c}
 
 	case !hasExeCtx && !hasQryCtx && !hasColConv && hasNamValChk:
@@ -115,21 +115,21 @@ func wrapStmt(ctx context.Context, stmt driver.Stmt, query string, options Optio
 	case !hasExeCtx && !hasQryCtx && hasColConv && hasNamValChk:
 		return struct {
 			driver.Stmt
-			driver.ColumnConverter
+			withColumnConverter
 			driver.NamedValueChecker
 		}{s, c, n}
 	case !hasExeCtx && hasQryCtx && hasColConv && hasNamValChk:
 		return struct {
 			driver.Stmt
 			driver.StmtQueryContext
-			driver.ColumnConverter
+			withColumnConverter
 			driver.NamedValueChecker
 		}{s, s, c, n}
 	case hasExeCtx && !hasQryCtx && hasColConv && hasNamValChk:
 		return struct {
 			driver.Stmt
 			driver.StmtExecContext
-			driver.ColumnConverter
+			withColumnConverter
 			driver.NamedValueChecker
 		}{s, s, c, n}
 	case hasExeCtx && hasQryCtx && hasColConv && hasNamValChk:
@@ -137,7 +137,7 @@ func wrapStmt(ctx context.Context, stmt driver.Stmt, query string, options Optio
 			driver.Stmt
 			driver.StmtExecContext
 			driver.StmtQueryContext
-			driver.ColumnConverter
+			withColumnConverter
 			driver.NamedValueChecker
 		}{s, s, s, c, n}
 	}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql/driver"
 	"errors"
-	"io"
 	"reflect"
 	"testing"
 )
@@ -124,41 +123,28 @@ func TestWrappingFallback(t *testing.T) {
 		t.Errorf("rows.Next want: %v, have: %v", want, have)
 	}
 
-	if want, have := false, wRows.(driver.RowsNextResultSet).HasNextResultSet(); want != have {
-		t.Errorf("rows.HasNextResultSet want: %t, have: %t", want, have)
-	}
-
-	if want, have := io.EOF, wRows.(driver.RowsNextResultSet).NextResultSet(); want != have {
-		t.Errorf("rows.NextResultSet want: %v, have: %v", want, have)
+	// Optional interfaces are only implemented if parent implements them.
+	if _, ok := wRows.(driver.RowsNextResultSet); ok {
+		t.Error("rows.NextResultSet unexpected interface implementation found")
 	}
 
 	if _, ok := wRows.(driver.RowsColumnTypeScanType); ok {
 		t.Error("rows.ColumnTypeScanType unexpected interface implementation found")
 	}
 
-	if want, have := "", wRows.(driver.RowsColumnTypeDatabaseTypeName).ColumnTypeDatabaseTypeName(1); want != have {
-		t.Errorf("rows.ColumnTypeDatabaseTypeName want: %s, have: %s", want, have)
+	if _, ok := wRows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		t.Error("rows.ColumnTypeDatabaseTypeName unexpected interface implementation found")
 	}
 
-	oLength, oOk := int64(0), false
-	wLength, wOk := wRows.(driver.RowsColumnTypeLength).ColumnTypeLength(1)
-
-	if oLength != wLength || oOk != wOk {
-		t.Errorf("rows.ColumnTypeLength want: %d:%t, have %d:%t", oLength, oOk, wLength, wOk)
+	if _, ok := wRows.(driver.RowsColumnTypeLength); ok {
+		t.Error("rows.ColumnTypeLength unexpected interface implementation found")
 	}
 
-	oNullable, oOk := false, false
-	wNullable, wOk := wRows.(driver.RowsColumnTypeNullable).ColumnTypeNullable(1)
-
-	if oNullable != wNullable || oOk != wOk {
-		t.Errorf("rows.ColumnTypeNullable want: %t:%t, have %t:%t", oNullable, oOk, wNullable, wOk)
+	if _, ok := wRows.(driver.RowsColumnTypeNullable); ok {
+		t.Error("rows.ColumnTypeNullable unexpected interface implementation found")
 	}
 
-	oPrecision, oScale, oOk := int64(0), int64(0), false
-	wPrecision, wScale, wOk := wRows.(driver.RowsColumnTypePrecisionScale).ColumnTypePrecisionScale(1)
-
-	if oPrecision != wPrecision || oScale != wScale || oOk != wOk {
-		t.Errorf("rows.ColumnTypePrecisionScale want: %d:%d:%t, have %d:%d:%t",
-			oPrecision, oScale, oOk, wPrecision, wScale, wOk)
+	if _, ok := wRows.(driver.RowsColumnTypePrecisionScale); ok {
+		t.Error("rows.ColumnTypePrecisionScale unexpected interface implementation found")
 	}
 }
@@ -0,0 +1,156 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// Event describes an operation for EventMiddleware and EventInterceptor.
+type Event struct {
+	Operation Operation
+
+	// Statement is a statement of operation, interceptor can change it.
+	Statement string
+
+	// Args are arguments of operation, interceptor can change them.
+	Args []driver.NamedValue
+
+	// ConnID identifies wrapped connection of operation, zero if unknown.
+	ConnID int64
+
+	// TxID identifies transaction of operation, zero for autocommit.
+	TxID int64
+
+	// TxReadOnly is true for operations of read-only transaction.
+	TxReadOnly bool
+
+	// Start is a time of operation start.
+	Start time.Time
+
+	// Duration is a time spent in operation, it is available in finalizer.
+	Duration time.Duration
+
+	// Result is a result of Exec and StmtExec, it is available in finalizer.
+	Result driver.Result
+
+	ctx context.Context
+	op  *opState
+}
+
+// Caller returns caller of operation, see CallerCtx.
+func (e *Event) Caller() string {
+	return CallerCtx(e.ctx)
+}
+
+// Parsed returns parsed statement of operation, see StatementInfo.
+func (e *Event) Parsed() (Statement, bool) {
+	return StatementInfo(e.ctx)
+}
+
+// newEvent creates event of operation from context.
+func newEvent(ctx context.Context, operation Operation, statement string, args []driver.NamedValue) *Event {
+	e := &Event{
+		Operation: operation,
+		Statement: statement,
+		Args:      args,
+		Start:     time.Now(),
+		ctx:       ctx,
+	}
+
+	if p, ok := ctx.Value(statementCtxKey{}).(*opState); ok && p.operation == operation {
+		e.op = p
+
+		if p.conn != nil {
+			e.ConnID = p.conn.id
+		}
+	}
+
+	if tx := txFromContext(ctx); tx != nil {
+		e.TxID = tx.id
+		e.TxReadOnly = tx.readOnly
+	}
+
+	return e
+}
+
+// EventMiddleware returns instrumented context and finalizer callback.
+//
+// It is an alternative to Middleware that receives operation as Event,
+// finalizer receives the same Event with Duration and Result.
+type EventMiddleware func(ctx context.Context, e *Event) (nCtx context.Context, onFinish func(e *Event, err error))
+
+// Middleware adapts EventMiddleware to Middleware.
+func (mw EventMiddleware) Middleware() Middleware {
+	return func(
+		ctx context.Context,
+		operation Operation,
+		statement string,
+		args []driver.NamedValue,
+	) (context.Context, func(error)) {
+		e := newEvent(ctx, operation, statement, args)
+
+		ctx, onFinish := mw(ctx, e)
+		if onFinish == nil {
+			return ctx, nil
+		}
+
+		return ctx, func(err error) {
+			e.Duration = time.Since(e.Start)
+
+			if e.op != nil {
+				e.Result = e.op.result
+			}
+
+			onFinish(e, err)
+		}
+	}
+}
+
+// WithEventMiddleware adds one or multiple event middlewares to a db wrapper.
+//
+// Event middlewares are chained with middlewares of WithMiddleware in order of options.
+func WithEventMiddleware(mw ...EventMiddleware) Option {
+	return func(o *Options) {
+		for _, m := range mw {
+			o.Middlewares = append(o.Middlewares, m.Middleware())
+		}
+	}
+}
+
+// EventInterceptor changes Statement and/or Args of Event before operation.
+type EventInterceptor func(ctx context.Context, e *Event) context.Context
+
+// Interceptor adapts EventInterceptor to the signature of Options.Intercept.
+func (i EventInterceptor) Interceptor() func(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+) (context.Context, string, []driver.NamedValue) {
+	return func(
+		ctx context.Context,
+		operation Operation,
+		statement string,
+		args []driver.NamedValue,
+	) (context.Context, string, []driver.NamedValue) {
+		e := newEvent(ctx, operation, statement, args)
+		ctx = i(ctx, e)
+
+		return ctx, e.Statement, e.Args
+	}
+}
+
+// WithEventInterceptor adds statement interceptor to a db wrapper,
+// it is chained after interceptor that is already set.
+func WithEventInterceptor(i EventInterceptor) Option {
+	return func(o *Options) {
+		if o.Intercept == nil {
+			o.Intercept = i.Interceptor()
+
+			return
+		}
+
+		o.Intercept = chainInterceptors(o.Intercept, i.Interceptor())
+	}
+}
@@ -0,0 +1,134 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithEventMiddleware(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		f        = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		finished []dbwrap.Event
+		connIDs  []int64
+		callers  []string
+	)
+
+	f.Exec = func(ctx context.Context, statement string, args []driver.NamedValue) (driver.Result, error) {
+		return driver.RowsAffected(3), nil
+	}
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(),
+		dbwrap.WithOperations(dbwrap.Exec, dbwrap.Begin, dbwrap.Commit),
+		dbwrap.WithEventInterceptor(func(ctx context.Context, e *dbwrap.Event) context.Context {
+			connIDs = append(connIDs, e.ConnID)
+			callers = append(callers, e.Caller())
+			e.Statement += " /* intercepted */"
+
+			return ctx
+		}),
+		dbwrap.WithEventMiddleware(func(ctx context.Context, e *dbwrap.Event) (context.Context, func(*dbwrap.Event, error)) {
+			return ctx, func(e *dbwrap.Event, err error) {
+				assert.NoError(t, err)
+				callers = append(callers, e.Caller())
+				finished = append(finished, *e)
+			}
+		}),
+	))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM t WHERE id = ?", 1)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	require.Len(t, finished, 3)
+	require.Len(t, connIDs, 1)
+
+	assert.Equal(t, dbwrap.Begin, finished[0].Operation)
+	assert.Equal(t, dbwrap.Commit, finished[2].Operation)
+
+	e := finished[1]
+	assert.Equal(t, dbwrap.Exec, e.Operation)
+	assert.Equal(t, "DELETE FROM t WHERE id = ? /* intercepted */", e.Statement)
+	assert.Equal(t, []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}, e.Args)
+	assert.Equal(t, connIDs[0], e.ConnID)
+	assert.NotZero(t, e.ConnID)
+	assert.NotZero(t, e.TxID)
+	assert.True(t, e.TxReadOnly)
+	assert.False(t, e.Start.IsZero())
+	assert.Equal(t, finished[2].TxID, e.TxID)
+
+	n, err := e.Result.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	st, ok := e.Parsed()
+	assert.True(t, ok)
+	assert.Equal(t, "DELETE", st.Kind)
+
+	for _, c := range callers {
+		assert.Equal(t, "bool64/dbwrap_test.TestWithEventMiddleware", c)
+	}
+}
+
+func TestWithEventInterceptor_txStmt(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		f     = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		txIDs []int64
+		inTx  []bool
+	)
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(),
+		dbwrap.WithOperations(dbwrap.StmtExec),
+		dbwrap.WithEventInterceptor(func(ctx context.Context, e *dbwrap.Event) context.Context {
+			if e.Operation == dbwrap.StmtExec {
+				txIDs = append(txIDs, e.TxID)
+			}
+
+			return ctx
+		}),
+		dbwrap.WithMiddleware(func(
+			ctx context.Context,
+			operation dbwrap.Operation,
+			statement string,
+			args []driver.NamedValue,
+		) (context.Context, func(error)) {
+			inTx = append(inTx, dbwrap.InTx(ctx))
+
+			return ctx, nil
+		}),
+	))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	st, err := db.PrepareContext(ctx, "DELETE FROM t WHERE id = ?")
+	require.NoError(t, err)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.StmtContext(ctx, st).ExecContext(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	require.NoError(t, st.Close())
+
+	require.Len(t, txIDs, 1)
+	assert.NotZero(t, txIDs[0])
+	assert.Equal(t, []bool{true}, inTx)
+}
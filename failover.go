@@ -0,0 +1,355 @@
+//go:build go1.10
+// +build go1.10
+
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Compile time assertions.
+var (
+	_ driver.Connector = &FailoverConnector{}
+	_ io.Closer        = &FailoverConnector{}
+)
+
+// ErrNoEndpoints is returned by FailoverConnector that has no connectors.
+var ErrNoEndpoints = errors.New("dbwrap: no failover endpoints")
+
+// FailoverConfig controls behavior of FailoverConnector.
+type FailoverConfig struct {
+	// ProbeInterval is a period of background health checks for endpoints that are down, default 5s.
+	ProbeInterval time.Duration
+
+	// ProbeTimeout limits duration of a single health check, default is ProbeInterval.
+	ProbeTimeout time.Duration
+
+	// IsConnError classifies errors that mark endpoint down, default IsConnError.
+	IsConnError func(err error) bool
+
+	// OnStateChange is called when endpoint, identified by its index, changes health state.
+	// The err is the cause of failure for unhealthy state and nil for healthy state.
+	OnStateChange func(endpoint int, healthy bool, err error)
+
+	// Options wrap connections of endpoints, so that middlewares and hooks also see errors that fail over.
+	// Configuration can be updated at runtime with HandleOf of FailoverConnector.
+	Options []Option
+}
+
+// FailoverConnector is a driver.Connector that dials the first healthy endpoint of an ordered list.
+//
+// Endpoint is marked down when it fails to connect or when its connection meets an error
+// classified by FailoverConfig.IsConnError. Endpoints that are down are probed in background
+// and are marked healthy again once they accept connections.
+//
+// Connections report themselves as bad to database/sql pool (with driver.ErrBadConn on session reset)
+// after a connection error or when an endpoint with higher priority becomes healthy, this way pool
+// fails over to another endpoint and fails back to preferred endpoint when it recovers.
+type FailoverConnector struct {
+	config    FailoverConfig
+	endpoints []*endpoint
+	options   Options
+
+	mu      sync.Mutex
+	probing bool
+	closed  chan struct{}
+	once    sync.Once
+}
+
+// endpoint is an element of failover list.
+type endpoint struct {
+	index     int
+	connector driver.Connector
+	down      int32
+}
+
+func (e *endpoint) isDown() bool {
+	return atomic.LoadInt32(&e.down) == 1
+}
+
+// NewFailoverConnector creates failover connector for ordered list of connectors.
+//
+// Connectors are prioritized by their order, first connector is preferred.
+func NewFailoverConnector(config FailoverConfig, connectors ...driver.Connector) *FailoverConnector {
+	if config.ProbeInterval == 0 {
+		config.ProbeInterval = 5 * time.Second
+	}
+
+	if config.ProbeTimeout == 0 {
+		config.ProbeTimeout = config.ProbeInterval
+	}
+
+	if config.IsConnError == nil {
+		config.IsConnError = IsConnError
+	}
+
+	f := &FailoverConnector{
+		config: config,
+		closed: make(chan struct{}),
+	}
+
+	f.options, _ = prepareOptions(append(append([]Option(nil), config.Options...), WithHandle()))
+
+	for i, c := range connectors {
+		f.endpoints = append(f.endpoints, &endpoint{index: i, connector: c})
+	}
+
+	return f
+}
+
+// Connect implements driver.Connector.
+//
+// It dials healthy endpoints in order of priority, if all of them fail, endpoints that are down are tried.
+// Every endpoint is dialed at most once per call.
+func (f *FailoverConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if len(f.endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	var (
+		lastErr error
+		wasDown = make([]bool, len(f.endpoints))
+	)
+
+	for i, e := range f.endpoints {
+		wasDown[i] = e.isDown()
+	}
+
+	for _, down := range []bool{false, true} {
+		for i, e := range f.endpoints {
+			if wasDown[i] != down {
+				continue
+			}
+
+			c, err := e.connector.Connect(ctx)
+			if err == nil {
+				f.markUp(e)
+
+				options := f.options
+				options.observer = &connHealth{failover: f, endpoint: e}
+
+				return wrapConn(c, options), nil
+			}
+
+			// Canceled context is not a failure of endpoint.
+			if ctx.Err() != nil {
+				return nil, err
+			}
+
+			f.markDown(e, err)
+
+			lastErr = err
+		}
+	}
+
+	return nil, lastErr
+}
+
+func (f *FailoverConnector) handle() *Handle {
+	return f.options.handle
+}
+
+// Driver implements driver.Connector.
+//
+// It returns driver of preferred endpoint.
+func (f *FailoverConnector) Driver() driver.Driver {
+	if len(f.endpoints) == 0 {
+		return nil
+	}
+
+	return f.endpoints[0].connector.Driver()
+}
+
+// Close stops background probing and closes connectors that implement io.Closer.
+func (f *FailoverConnector) Close() error {
+	var err error
+
+	f.once.Do(func() {
+		close(f.closed)
+
+		for _, e := range f.endpoints {
+			if c, ok := e.connector.(io.Closer); ok {
+				if cErr := c.Close(); cErr != nil && err == nil {
+					err = cErr
+				}
+			}
+		}
+	})
+
+	return err
+}
+
+// Healthy reports health of endpoints in order of priority.
+func (f *FailoverConnector) Healthy() []bool {
+	res := make([]bool, 0, len(f.endpoints))
+
+	for _, e := range f.endpoints {
+		res = append(res, !e.isDown())
+	}
+
+	return res
+}
+
+// preferred returns index of the first healthy endpoint or number of endpoints if all are down.
+func (f *FailoverConnector) preferred() int {
+	for _, e := range f.endpoints {
+		if !e.isDown() {
+			return e.index
+		}
+	}
+
+	return len(f.endpoints)
+}
+
+func (f *FailoverConnector) markUp(e *endpoint) {
+	if atomic.CompareAndSwapInt32(&e.down, 1, 0) && f.config.OnStateChange != nil {
+		f.config.OnStateChange(e.index, true, nil)
+	}
+}
+
+func (f *FailoverConnector) markDown(e *endpoint, err error) {
+	if !atomic.CompareAndSwapInt32(&e.down, 0, 1) {
+		return
+	}
+
+	if f.config.OnStateChange != nil {
+		f.config.OnStateChange(e.index, false, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	select {
+	case <-f.closed:
+		return
+	default:
+	}
+
+	if !f.probing {
+		f.probing = true
+
+		go f.probe()
+	}
+}
+
+// probe checks endpoints that are down until all of them are healthy or connector is closed.
+func (f *FailoverConnector) probe() {
+	ticker := time.NewTicker(f.config.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.closed:
+			return
+		case <-ticker.C:
+		}
+
+		for _, e := range f.endpoints {
+			if e.isDown() && f.check(e) == nil {
+				f.markUp(e)
+			}
+		}
+
+		f.mu.Lock()
+
+		if !f.anyDown() {
+			f.probing = false
+			f.mu.Unlock()
+
+			return
+		}
+
+		f.mu.Unlock()
+	}
+}
+
+func (f *FailoverConnector) anyDown() bool {
+	for _, e := range f.endpoints {
+		if e.isDown() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check dials endpoint and pings connection if driver supports it.
+func (f *FailoverConnector) check(e *endpoint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), f.config.ProbeTimeout)
+	defer cancel()
+
+	c, err := e.connector.Connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	if p, ok := c.(driver.Pinger); ok {
+		err = p.Ping(ctx)
+	}
+
+	if cErr := c.Close(); err == nil {
+		err = cErr
+	}
+
+	return err
+}
+
+// connHealth observes errors of a connection dialed by FailoverConnector.
+type connHealth struct {
+	failover *FailoverConnector
+	endpoint *endpoint
+	bad      int32
+}
+
+func (h *connHealth) observe(err error) {
+	if !h.failover.config.IsConnError(err) {
+		return
+	}
+
+	atomic.StoreInt32(&h.bad, 1)
+	h.failover.markDown(h.endpoint, err)
+}
+
+// isBad reports whether connection has failed, or its endpoint is down,
+// or an endpoint with higher priority is available.
+func (h *connHealth) isBad() bool {
+	return atomic.LoadInt32(&h.bad) == 1 ||
+		h.endpoint.isDown() ||
+		h.failover.preferred() < h.endpoint.index
+}
+
+// IsConnError reports whether err indicates a broken database connection.
+//
+// It detects driver.ErrBadConn, network errors and unexpected end of stream, errors are unwrapped with Unwrap.
+// Network timeouts are not connection errors, because a slow query can time out on a healthy endpoint.
+func IsConnError(err error) bool {
+	for err != nil {
+		if err == driver.ErrBadConn || err == io.ErrUnexpectedEOF {
+			return true
+		}
+
+		switch e := err.(type) {
+		case syscall.Errno:
+			return e == syscall.ECONNRESET || e == syscall.ECONNREFUSED || e == syscall.EPIPE
+		case net.Error:
+			return !e.Timeout()
+		}
+
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+
+		err = u.Unwrap()
+	}
+
+	return false
+}
@@ -0,0 +1,237 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bool64/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubEndpoint struct {
+	mu         sync.Mutex
+	name       string
+	connectErr error
+	queryErr   error
+	connects   int
+}
+
+func (e *stubEndpoint) set(connectErr, queryErr error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.connectErr = connectErr
+	e.queryErr = queryErr
+}
+
+func (e *stubEndpoint) Connect(_ context.Context) (driver.Conn, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.connects++
+
+	if e.connectErr != nil {
+		return nil, e.connectErr
+	}
+
+	return stubConn{e: e}, nil
+}
+
+func (e *stubEndpoint) Driver() driver.Driver {
+	return nil
+}
+
+type stubConn struct {
+	e *stubEndpoint
+}
+
+func (c stubConn) Prepare(_ string) (driver.Stmt, error) { return nil, errors.New("not implemented") }
+func (c stubConn) Close() error                          { return nil }
+func (c stubConn) Begin() (driver.Tx, error)             { return nil, errors.New("not implemented") }
+
+func (c stubConn) QueryContext(_ context.Context, _ string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.e.mu.Lock()
+	defer c.e.mu.Unlock()
+
+	if c.e.queryErr != nil {
+		return nil, c.e.queryErr
+	}
+
+	return &stubNameRows{name: c.e.name}, nil
+}
+
+type stubNameRows struct {
+	name string
+	done bool
+}
+
+func (r *stubNameRows) Columns() []string { return []string{"name"} }
+func (r *stubNameRows) Close() error      { return nil }
+
+func (r *stubNameRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+
+	r.done = true
+	dest[0] = r.name
+
+	return nil
+}
+
+func queryName(t *testing.T, db *sql.DB) string {
+	t.Helper()
+
+	var name string
+
+	require.NoError(t, db.QueryRow("SELECT name").Scan(&name))
+
+	return name
+}
+
+func TestNewFailoverConnector(t *testing.T) {
+	var (
+		primary   = &stubEndpoint{name: "primary"}
+		secondary = &stubEndpoint{name: "secondary"}
+		mu        sync.Mutex
+		changes   []string
+		errs      []string
+	)
+
+	f := dbwrap.NewFailoverConnector(dbwrap.FailoverConfig{
+		Options: []dbwrap.Option{dbwrap.WithMiddleware(
+			func(ctx context.Context, _ dbwrap.Operation, _ string, _ []driver.NamedValue) (context.Context, func(error)) {
+				return ctx, func(err error) {
+					if err != nil {
+						mu.Lock()
+						defer mu.Unlock()
+
+						errs = append(errs, err.Error())
+					}
+				}
+			},
+		)},
+		ProbeInterval: 10 * time.Millisecond,
+		OnStateChange: func(endpoint int, healthy bool, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if healthy {
+				changes = append(changes, "up")
+			} else {
+				changes = append(changes, "down: "+err.Error())
+			}
+		},
+	}, primary, secondary)
+
+	db := sql.OpenDB(f)
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	assert.Equal(t, "primary", queryName(t, db))
+
+	// Connection error on primary evicts connection and fails over to secondary.
+	primary.set(errors.New("connection refused"), driver.ErrBadConn)
+
+	assert.Equal(t, "secondary", queryName(t, db))
+	assert.Equal(t, []bool{false, true}, f.Healthy())
+
+	// Not connection-level errors do not affect health.
+	secondary.set(nil, errors.New("syntax error"))
+
+	err := db.QueryRow("SELECT name").Scan(new(string))
+	require.EqualError(t, err, "syntax error")
+	assert.Equal(t, []bool{false, true}, f.Healthy())
+
+	secondary.set(nil, nil)
+
+	// Primary recovers and pool fails back to it.
+	primary.set(nil, nil)
+
+	require.Eventually(t, func() bool {
+		return f.Healthy()[0]
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, "primary", queryName(t, db))
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, []string{"down: driver: bad connection", "up"}, changes)
+	assert.Equal(t, []string{"driver: bad connection", "syntax error"}, errs)
+	assert.NotNil(t, dbwrap.HandleOf(f))
+}
+
+func TestFailoverConnector_Connect_allDown(t *testing.T) {
+	var (
+		primary   = &stubEndpoint{name: "primary", connectErr: errors.New("failed primary")}
+		secondary = &stubEndpoint{name: "secondary", connectErr: errors.New("failed secondary")}
+	)
+
+	f := dbwrap.NewFailoverConnector(dbwrap.FailoverConfig{ProbeInterval: time.Hour}, primary, secondary)
+
+	_, err := f.Connect(context.Background())
+	require.EqualError(t, err, "failed secondary")
+	assert.Equal(t, []bool{false, false}, f.Healthy())
+
+	// Every endpoint is dialed once per call.
+	assert.Equal(t, 1, primary.connects)
+	assert.Equal(t, 1, secondary.connects)
+
+	_, err = f.Connect(context.Background())
+	require.EqualError(t, err, "failed secondary")
+	assert.Equal(t, 2, primary.connects)
+	assert.Equal(t, 2, secondary.connects)
+
+	// Endpoints that are down are still tried when there is no healthy endpoint.
+	secondary.set(nil, nil)
+
+	c, err := f.Connect(context.Background())
+	require.NoError(t, err)
+
+	// Connections of failover connector share runtime configuration.
+	assert.NotNil(t, dbwrap.HandleOf(c))
+	require.NoError(t, c.Close())
+	assert.Equal(t, []bool{false, true}, f.Healthy())
+
+	require.NoError(t, f.Close())
+
+	_, err = dbwrap.NewFailoverConnector(dbwrap.FailoverConfig{}).Connect(context.Background())
+	assert.Equal(t, dbwrap.ErrNoEndpoints, err)
+}
+
+func TestIsConnError(t *testing.T) {
+	assert.True(t, dbwrap.IsConnError(driver.ErrBadConn))
+	assert.True(t, dbwrap.IsConnError(io.ErrUnexpectedEOF))
+	assert.False(t, dbwrap.IsConnError(io.EOF))
+	assert.False(t, dbwrap.IsConnError(errors.New("failed")))
+	assert.False(t, dbwrap.IsConnError(nil))
+	assert.True(t, dbwrap.IsConnError(syscall.ECONNRESET))
+	assert.False(t, dbwrap.IsConnError(syscall.ENOENT))
+	assert.True(t, dbwrap.IsConnError(&net.OpError{Op: "read", Err: errors.New("broken pipe")}))
+	assert.False(t, dbwrap.IsConnError(&net.DNSError{IsTimeout: true}))
+	assert.True(t, dbwrap.IsConnError(wrappedError{driver.ErrBadConn}))
+	assert.False(t, dbwrap.IsConnError(wrappedError{&net.DNSError{IsTimeout: true}}))
+}
+
+type wrappedError struct {
+	err error
+}
+
+func (e wrappedError) Error() string {
+	return "wrapped: " + e.err.Error()
+}
+
+func (e wrappedError) Unwrap() error {
+	return e.err
+}
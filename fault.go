@@ -0,0 +1,382 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Fault describes a failure to inject into matching operations.
+//
+// Matching conditions are combined, empty condition matches any operation.
+type Fault struct {
+	// Name is an optional label of fault.
+	Name string `json:"name,omitempty"`
+
+	// Operations limits fault to listed operations.
+	Operations []Operation `json:"operations,omitempty"`
+
+	// Statement is a regular expression to match statement.
+	Statement string `json:"statement,omitempty"`
+
+	// Caller is a regular expression to match caller, see CallerCtx.
+	Caller string `json:"caller,omitempty"`
+
+	// Probability of fault in range (0, 1], zero value means fault is always injected.
+	Probability float64 `json:"probability,omitempty"`
+
+	// Latency is added before operation, it is interrupted by context cancellation.
+	// In JSON latency is a duration string, e.g. "1.5s".
+	Latency time.Duration `json:"-"`
+
+	// Error is a message of returned error.
+	// Messages of driver.ErrBadConn, io.ErrUnexpectedEOF and context.DeadlineExceeded
+	// are resolved to those errors.
+	Error string `json:"error,omitempty"`
+
+	// Err is returned instead of Error if not nil.
+	Err error `json:"-"`
+
+	// TruncateAfter applies to Query and StmtQuery, rows end after the number of rows
+	// with error of fault or with io.EOF if there is no error.
+	TruncateAfter *int `json:"truncateAfter,omitempty"`
+
+	// AfterCommit applies to Commit and Rollback, fault is injected after successful driver call,
+	// this simulates a lost acknowledgement of commit.
+	AfterCommit bool `json:"afterCommit,omitempty"`
+
+	statement *regexp.Regexp
+	caller    *regexp.Regexp
+}
+
+// FaultInjector fails operations according to faults that can be changed at runtime.
+//
+// FaultInjector implements http.Handler to control faults with JSON API:
+// GET returns a list of faults, PUT or POST replaces faults with a list from request body,
+// DELETE removes all faults.
+//
+// Failing Commit or Rollback happens before the driver call and driver transaction is rolled back,
+// use Fault.AfterCommit to fail after successful driver call.
+type FaultInjector struct {
+	mu     sync.Mutex
+	faults []Fault
+	rnd    *rand.Rand
+}
+
+// NewFaultInjector creates FaultInjector with faults.
+func NewFaultInjector(faults ...Fault) (*FaultInjector, error) {
+	fi := &FaultInjector{
+		rnd: rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec // Chaos does not need crypto.
+	}
+
+	if err := fi.SetFaults(faults...); err != nil {
+		return nil, err
+	}
+
+	return fi, nil
+}
+
+// WithFaultInjector adds fault injection to a db wrapper.
+func WithFaultInjector(fi *FaultInjector) Option {
+	return withHook(hook{
+		query: fi.query,
+		exec:  fi.exec,
+		call:  fi.call,
+	})
+}
+
+// SetFaults replaces faults.
+func (fi *FaultInjector) SetFaults(faults ...Fault) error {
+	prepared := make([]Fault, 0, len(faults))
+
+	for _, f := range faults {
+		var err error
+
+		if f.Statement != "" {
+			if f.statement, err = regexp.Compile(f.Statement); err != nil {
+				return err
+			}
+		}
+
+		if f.Caller != "" {
+			if f.caller, err = regexp.Compile(f.Caller); err != nil {
+				return err
+			}
+		}
+
+		if f.Probability < 0 || f.Probability > 1 {
+			return errors.New("fault probability must be in range [0, 1]")
+		}
+
+		prepared = append(prepared, f)
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	fi.faults = prepared
+
+	return nil
+}
+
+// Faults returns current faults.
+func (fi *FaultInjector) Faults() []Fault {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	return append([]Fault(nil), fi.faults...)
+}
+
+// ServeHTTP controls faults.
+func (fi *FaultInjector) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var faults []Fault
+
+		if err := json.NewDecoder(r.Body).Decode(&faults); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		if err := fi.SetFaults(faults...); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	case http.MethodDelete:
+		_ = fi.SetFaults() //nolint:errcheck // Empty faults are valid.
+	default:
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	faults := fi.Faults()
+	if faults == nil {
+		faults = []Fault{}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(rw).Encode(faults) //nolint:errcheck // Best effort.
+}
+
+type fault Fault
+
+// MarshalJSON encodes fault with latency as a duration string.
+func (f Fault) MarshalJSON() ([]byte, error) {
+	v := struct {
+		fault
+		Latency string `json:"latency,omitempty"`
+	}{fault: fault(f)}
+
+	if f.Latency != 0 {
+		v.Latency = f.Latency.String()
+	}
+
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes fault with latency as a duration string.
+func (f *Fault) UnmarshalJSON(data []byte) error {
+	v := struct {
+		*fault
+		Latency string `json:"latency,omitempty"`
+	}{fault: (*fault)(f)}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if v.Latency == "" {
+		return nil
+	}
+
+	latency, err := time.ParseDuration(v.Latency)
+	if err != nil {
+		return err
+	}
+
+	f.Latency = latency
+
+	return nil
+}
+
+// match returns the first fault that matches operation.
+func (fi *FaultInjector) match(ctx context.Context, operation Operation, statement string) (Fault, bool) {
+	// Faults are replaced and never modified, so they are matched without lock.
+	fi.mu.Lock()
+	faults := fi.faults
+	fi.mu.Unlock()
+
+	for _, f := range faults {
+		if len(f.Operations) > 0 {
+			found := false
+
+			for _, op := range f.Operations {
+				if op == operation {
+					found = true
+
+					break
+				}
+			}
+
+			if !found {
+				continue
+			}
+		}
+
+		if f.statement != nil && !f.statement.MatchString(statement) {
+			continue
+		}
+
+		if f.caller != nil && !f.caller.MatchString(CallerCtx(ctx)) {
+			continue
+		}
+
+		if f.Probability > 0 && fi.random() >= f.Probability {
+			continue
+		}
+
+		return f, true
+	}
+
+	return Fault{}, false
+}
+
+// random returns a pseudo-random number in range [0, 1).
+func (fi *FaultInjector) random() float64 {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	return fi.rnd.Float64()
+}
+
+// inject adds latency and returns error of fault.
+func (f Fault) inject(ctx context.Context) error {
+	if f.Latency > 0 {
+		t := time.NewTimer(f.Latency)
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+
+			return ctx.Err()
+		}
+	}
+
+	return f.err()
+}
+
+func (f Fault) err() error {
+	if f.Err != nil {
+		return f.Err
+	}
+
+	switch f.Error {
+	case "":
+		return nil
+	case driver.ErrBadConn.Error():
+		return driver.ErrBadConn
+	case io.ErrUnexpectedEOF.Error():
+		return io.ErrUnexpectedEOF
+	case context.DeadlineExceeded.Error():
+		return context.DeadlineExceeded
+	}
+
+	return errors.New(f.Error)
+}
+
+func (fi *FaultInjector) query(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+	next func(ctx context.Context) (driver.Rows, error),
+) (driver.Rows, error) {
+	f, ok := fi.match(ctx, operation, statement)
+	if !ok {
+		return next(ctx)
+	}
+
+	if f.TruncateAfter == nil {
+		if err := f.inject(ctx); err != nil {
+			return nil, err
+		}
+
+		return next(ctx)
+	}
+
+	rows, err := next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	left := *f.TruncateAfter
+
+	return decorateRows(rows, func(dest []driver.Value) error {
+		if left <= 0 {
+			if err := f.inject(ctx); err != nil {
+				return err
+			}
+
+			return io.EOF
+		}
+
+		left--
+
+		return rows.Next(dest)
+	}, rows.Close), nil
+}
+
+func (fi *FaultInjector) exec(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+	next func(ctx context.Context) (driver.Result, error),
+) (driver.Result, error) {
+	if f, ok := fi.match(ctx, operation, statement); ok {
+		if err := f.inject(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return next(ctx)
+}
+
+func (fi *FaultInjector) call(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	next func(ctx context.Context) error,
+) error {
+	f, ok := fi.match(ctx, operation, statement)
+	if !ok {
+		return next(ctx)
+	}
+
+	if f.AfterCommit && (operation == Commit || operation == Rollback) {
+		if err := next(ctx); err != nil {
+			return err
+		}
+
+		return f.inject(ctx)
+	}
+
+	if err := f.inject(ctx); err != nil {
+		return err
+	}
+
+	return next(ctx)
+}
@@ -0,0 +1,197 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithFaultInjector(t *testing.T) {
+	ctx := context.Background()
+	f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+
+	f.Query = func(ctx context.Context, statement string, args []driver.NamedValue) (*dbwraptest.Rows, error) {
+		return dbwraptest.NewRows("a").AddRow(int64(1)).AddRow(int64(2)).AddRow(int64(3)), nil
+	}
+
+	two := 2
+
+	fi, err := dbwrap.NewFaultInjector(
+		dbwrap.Fault{Operations: []dbwrap.Operation{dbwrap.Exec}, Statement: "^DELETE", Error: "driver: bad connection"},
+		dbwrap.Fault{Operations: []dbwrap.Operation{dbwrap.Query}, TruncateAfter: &two, Error: "unexpected EOF"},
+		dbwrap.Fault{Operations: []dbwrap.Operation{dbwrap.Commit}, Error: "commit failed"},
+	)
+	require.NoError(t, err)
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithFaultInjector(fi)))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err = db.ExecContext(ctx, "UPDATE t SET a = 1")
+	require.NoError(t, err)
+
+	// Bad connection is returned after retries of database/sql.
+	_, err = db.ExecContext(ctx, "DELETE FROM t")
+	assert.Equal(t, driver.ErrBadConn, err)
+
+	rows, err := db.QueryContext(ctx, "SELECT a FROM t")
+	require.NoError(t, err)
+
+	cnt := 0
+	for rows.Next() {
+		cnt++
+	}
+
+	assert.Equal(t, 2, cnt)
+	assert.EqualError(t, rows.Err(), "unexpected EOF")
+	require.NoError(t, rows.Close())
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	assert.EqualError(t, tx.Commit(), "commit failed")
+	assert.Equal(t, "Tx.Rollback", f.Methods()[len(f.Methods())-1])
+
+	// Commit fails after the driver call with AfterCommit.
+	require.NoError(t, fi.SetFaults(dbwrap.Fault{
+		Operations: []dbwrap.Operation{dbwrap.Commit}, Error: "commit failed", AfterCommit: true,
+	}))
+
+	tx, err = db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	assert.EqualError(t, tx.Commit(), "commit failed")
+	assert.Equal(t, "Tx.Commit", f.Methods()[len(f.Methods())-1])
+
+	// Faults are controlled at runtime.
+	rw := httptest.NewRecorder()
+	fi.ServeHTTP(rw, httptest.NewRequest(http.MethodPut, "/",
+		strings.NewReader(`[{"operations":["exec"],"caller":"TestWithFaultInjector","latency":"1ms","error":"boom"}]`)))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, `[{"operations":["exec"],"caller":"TestWithFaultInjector","error":"boom","latency":"1ms"}]`+"\n",
+		rw.Body.String())
+	assert.Equal(t, time.Millisecond, fi.Faults()[0].Latency)
+
+	_, err = db.ExecContext(ctx, "UPDATE t SET a = 1")
+	assert.EqualError(t, err, "boom")
+
+	rw = httptest.NewRecorder()
+	fi.ServeHTTP(rw, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`[{"statement":"("}]`)))
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+
+	rw = httptest.NewRecorder()
+	fi.ServeHTTP(rw, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`[{"latency":100}]`)))
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+
+	rw = httptest.NewRecorder()
+	fi.ServeHTTP(rw, httptest.NewRequest(http.MethodDelete, "/", nil))
+	assert.Equal(t, "[]\n", rw.Body.String())
+
+	_, err = db.ExecContext(ctx, "UPDATE t SET a = 1")
+	assert.NoError(t, err)
+}
+
+func TestWithFaultInjector_commitConnReuse(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		f      = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		openTx int
+		inTx   []bool
+	)
+
+	f.Begin = func(ctx context.Context, opts driver.TxOptions) error {
+		openTx++
+
+		return nil
+	}
+	f.Commit = func() error {
+		openTx--
+
+		return nil
+	}
+	f.Rollback = func() error {
+		openTx--
+
+		return nil
+	}
+	f.Exec = func(ctx context.Context, statement string, args []driver.NamedValue) (driver.Result, error) {
+		inTx = append(inTx, openTx > 0)
+
+		return driver.RowsAffected(1), nil
+	}
+
+	fi, err := dbwrap.NewFaultInjector(
+		dbwrap.Fault{Operations: []dbwrap.Operation{dbwrap.Commit}, Error: "commit failed"},
+	)
+	require.NoError(t, err)
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithFaultInjector(fi)))
+	db.SetMaxOpenConns(1)
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(ctx, "UPDATE t SET a = 1")
+	require.NoError(t, err)
+	assert.EqualError(t, tx.Commit(), "commit failed")
+
+	// Connection is reused without driver transaction.
+	_, err = db.ExecContext(ctx, "UPDATE t SET a = 2")
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, openTx)
+	assert.Equal(t, []bool{true, false}, inTx)
+}
+
+func TestFaultInjector_SetFaults_concurrent(t *testing.T) {
+	ctx := context.Background()
+	f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+
+	fi, err := dbwrap.NewFaultInjector()
+	require.NoError(t, err)
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithFaultInjector(fi)))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 50; j++ {
+				_, err := db.ExecContext(ctx, "UPDATE t SET a = 1")
+				if err != nil {
+					assert.EqualError(t, err, "failed")
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		require.NoError(t, fi.SetFaults(dbwrap.Fault{Statement: "^UPDATE", Caller: "dbwrap_test", Probability: 0.5, Error: "failed"}))
+		require.NoError(t, fi.SetFaults())
+	}
+
+	wg.Wait()
+}
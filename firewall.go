@@ -0,0 +1,213 @@
+package dbwrap
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// FirewallMode controls reaction of Firewall to statements that are not in allowlist.
+type FirewallMode int32
+
+// These constants enumerate firewall modes.
+const (
+	// FirewallLearn adds unknown statements to allowlist.
+	FirewallLearn = FirewallMode(iota)
+
+	// FirewallMonitor reports unknown statements with OnViolation and allows them.
+	FirewallMonitor
+
+	// FirewallEnforce reports unknown statements with OnViolation and rejects them with *FirewallError.
+	FirewallEnforce
+)
+
+// FirewallEntry is an allowed statement fingerprint with its caller.
+type FirewallEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Caller      string `json:"caller"`
+}
+
+// FirewallError describes a statement that is not in allowlist.
+type FirewallError struct {
+	FirewallEntry
+	Operation Operation
+	Statement string
+}
+
+// Error implements error.
+func (e *FirewallError) Error() string {
+	return "dbwrap: statement rejected by firewall: " + e.Fingerprint + " (caller " + e.Caller + ")"
+}
+
+// FirewallConfig controls Firewall.
+type FirewallConfig struct {
+	// Mode is an initial mode of firewall, default FirewallLearn.
+	Mode FirewallMode
+
+	// Learned receives new allowlist entries as JSON lines in learning mode.
+	Learned io.Writer
+
+	// CallerSkip lists packages to skip when detecting caller, see CallerCtx.
+	CallerSkip []string
+
+	// OnViolation is called for statements that are not in allowlist in monitor and enforcing modes.
+	OnViolation func(ctx context.Context, err *FirewallError)
+}
+
+// Firewall checks fingerprints of statements with their callers against allowlist.
+//
+// Exec, Query and Prepare are checked, prepared statements are only checked once.
+type Firewall struct {
+	config FirewallConfig
+	mode   int32
+
+	mu      sync.Mutex
+	allowed map[FirewallEntry]bool
+	err     error
+}
+
+// NewFirewall creates Firewall with empty allowlist.
+func NewFirewall(config FirewallConfig) *Firewall {
+	return &Firewall{
+		config:  config,
+		mode:    int32(config.Mode),
+		allowed: make(map[FirewallEntry]bool),
+	}
+}
+
+// WithFirewall adds firewall to a db wrapper.
+//
+// Statements are checked after statement interceptor, as they are sent to driver.
+func WithFirewall(f *Firewall) Option {
+	return withHook(hook{
+		check: f.check,
+	})
+}
+
+// SetMode changes firewall mode.
+func (f *Firewall) SetMode(mode FirewallMode) {
+	atomic.StoreInt32(&f.mode, int32(mode))
+}
+
+// Mode returns firewall mode.
+func (f *Firewall) Mode() FirewallMode {
+	return FirewallMode(atomic.LoadInt32(&f.mode))
+}
+
+// Load adds entries to allowlist from JSON lines, for example as written to FirewallConfig.Learned.
+func (f *Firewall) Load(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+
+		var e FirewallEntry
+
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return err
+		}
+
+		f.allowed[e] = true
+	}
+
+	return s.Err()
+}
+
+// Allow adds entries to allowlist.
+func (f *Firewall) Allow(entries ...FirewallEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, e := range entries {
+		f.allowed[e] = true
+	}
+}
+
+// Allowlist returns allowed entries.
+func (f *Firewall) Allowlist() []FirewallEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	res := make([]FirewallEntry, 0, len(f.allowed))
+
+	for e := range f.allowed {
+		res = append(res, e)
+	}
+
+	return res
+}
+
+// Err returns the first error of writing learned entries.
+func (f *Firewall) Err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.err
+}
+
+// check checks final statement of operation, it returns error if statement is rejected.
+func (f *Firewall) check(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	_ []driver.NamedValue,
+) (func(), error) {
+	e := FirewallEntry{
+		Fingerprint: Fingerprint(statement),
+		Caller:      CallerCtx(ctx, f.config.CallerSkip...),
+	}
+	mode := f.Mode()
+
+	f.mu.Lock()
+
+	if f.allowed[e] {
+		f.mu.Unlock()
+
+		return nil, nil
+	}
+
+	if mode == FirewallLearn {
+		defer f.mu.Unlock()
+
+		f.allowed[e] = true
+
+		if f.config.Learned != nil && f.err == nil {
+			b, err := json.Marshal(e)
+			if err == nil {
+				_, err = f.config.Learned.Write(append(b, '\n'))
+			}
+
+			f.err = err
+		}
+
+		return nil, nil
+	}
+
+	f.mu.Unlock()
+
+	err := &FirewallError{FirewallEntry: e, Operation: operation, Statement: statement}
+
+	report := func() {
+		if f.config.OnViolation != nil {
+			f.config.OnViolation(ctx, err)
+		}
+	}
+
+	if mode == FirewallEnforce {
+		report()
+
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,112 @@
+package dbwrap_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithFirewall(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		learned = bytes.NewBuffer(nil)
+		f       = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	)
+
+	learn := dbwrap.NewFirewall(dbwrap.FirewallConfig{Learned: learned})
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithFirewall(learn)))
+
+	_, err := db.ExecContext(ctx, "UPDATE t SET a = 1 WHERE b IN (1, 2)")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "UPDATE t SET a = 2 WHERE b IN (3)")
+	require.NoError(t, err)
+	require.NoError(t, learn.Err())
+	require.NoError(t, db.Close())
+
+	assert.Equal(t, `{"fingerprint":"UPDATE T SET A = ? WHERE B IN (?)","caller":"bool64/dbwrap_test.TestWithFirewall"}`+"\n",
+		learned.String())
+
+	var violations []string
+
+	fw := dbwrap.NewFirewall(dbwrap.FirewallConfig{
+		Mode: dbwrap.FirewallEnforce,
+		OnViolation: func(ctx context.Context, err *dbwrap.FirewallError) {
+			violations = append(violations, err.Fingerprint)
+		},
+	})
+	require.NoError(t, fw.Load(learned))
+
+	db = sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithFirewall(fw)))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err = db.ExecContext(ctx, "update t set a = 3 where b in (4, 5, 6)")
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, "UPDATE t SET a = 1 WHERE b = 1 OR 1 = 1")
+
+	fe, ok := err.(*dbwrap.FirewallError)
+	require.True(t, ok)
+	assert.Equal(t, "UPDATE T SET A = ? WHERE B = ? OR ? = ?", fe.Fingerprint)
+	assert.Equal(t, dbwrap.Exec, fe.Operation)
+
+	_, err = db.PrepareContext(ctx, "DELETE FROM t")
+	assert.EqualError(t, err, "dbwrap: statement rejected by firewall: DELETE FROM T (caller bool64/dbwrap_test.TestWithFirewall)")
+
+	fw.SetMode(dbwrap.FirewallMonitor)
+
+	_, err = db.ExecContext(ctx, "DELETE FROM t")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"UPDATE T SET A = ? WHERE B = ? OR ? = ?", "DELETE FROM T", "DELETE FROM T"}, violations)
+}
+
+func TestWithFirewall_intercept(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		f          = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		violations []string
+	)
+
+	fw := dbwrap.NewFirewall(dbwrap.FirewallConfig{
+		Mode: dbwrap.FirewallEnforce,
+		OnViolation: func(ctx context.Context, err *dbwrap.FirewallError) {
+			violations = append(violations, err.Statement)
+		},
+	})
+	fw.Allow(dbwrap.FirewallEntry{
+		Fingerprint: "DELETE FROM T WHERE A = ?",
+		Caller:      "bool64/dbwrap_test.TestWithFirewall_intercept",
+	})
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithFirewall(fw),
+		dbwrap.WithInterceptor(func(
+			ctx context.Context,
+			operation dbwrap.Operation,
+			statement string,
+			args []driver.NamedValue,
+		) (context.Context, string, []driver.NamedValue) {
+			return ctx, statement + " OR b = 1", args
+		})))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	// Allowed statement is rejected, as interceptor changes it.
+	_, err := db.ExecContext(ctx, "DELETE FROM t WHERE a = ?", 1)
+
+	_, ok := err.(*dbwrap.FirewallError)
+	require.True(t, ok)
+	assert.Equal(t, []string{"DELETE FROM t WHERE a = ? OR b = 1"}, violations)
+	assert.NotContains(t, f.Methods(), "Conn.ExecContext")
+}
@@ -0,0 +1,147 @@
+package dbwrap
+
+import (
+	"database/sql/driver"
+	"sync"
+	"sync/atomic"
+)
+
+// Handle reconfigures middlewares, interceptor and operations of a wrapper at runtime.
+//
+// Wrapped connections, statements and transactions read configuration of Handle on every operation
+// with an atomic load, so updates apply to operations started afterwards.
+type Handle struct {
+	mu      sync.Mutex
+	base    Options
+	initial *handleState
+	state   atomic.Value
+}
+
+// handleState is a snapshot of runtime configuration.
+type handleState struct {
+	// config keeps Middlewares, Intercept, Operations and filters as they were set by options.
+	config Options
+
+	middlewares []Middleware
+	filters     []middlewareFilter
+	intercept   interceptor
+	operations  map[Operation]bool
+}
+
+// WithHandle makes wrapper available for HandleOf even if there are no middlewares
+// or other options to apply yet.
+func WithHandle() Option {
+	return func(o *Options) {
+		o.withHandle = true
+	}
+}
+
+// HandleOf returns Handle of a wrapped driver.Driver, driver.Connector or driver.Conn,
+// or nil if value is not wrapped.
+//
+// For drivers of Register, Handle is available with HandleOf(db.Driver()).
+func HandleOf(v interface{}) *Handle {
+	if d, ok := v.(struct{ driver.Driver }); ok {
+		v = d.Driver
+	}
+
+	if h, ok := v.(interface{ handle() *Handle }); ok {
+		return h.handle()
+	}
+
+	return nil
+}
+
+func newHandle(base Options) *Handle {
+	h := &Handle{base: base}
+	h.initial = h.prepare(base)
+	h.state.Store(h.initial)
+
+	return h
+}
+
+// prepare builds configuration snapshot from base options and config.
+func (h *Handle) prepare(config Options) *handleState {
+	o := h.base
+	o.Middlewares = config.Middlewares
+	o.Intercept = config.Intercept
+	o.Operations = config.Operations
+	o.filters = append([]middlewareFilter(nil), config.filters...)
+
+	o.prepare()
+
+	return &handleState{
+		config: Options{
+			Middlewares: config.Middlewares,
+			Intercept:   config.Intercept,
+			Operations:  config.Operations,
+			filters:     config.filters,
+		},
+		middlewares: o.Middlewares,
+		filters:     o.filters,
+		intercept:   o.Intercept,
+		operations:  o.operations,
+	}
+}
+
+func (h *Handle) load() *handleState {
+	return h.state.Load().(*handleState)
+}
+
+// Update applies options on top of current configuration.
+//
+// Only middlewares, interceptor and operations are updated, other options are ignored.
+// Use WithOptions to replace configuration entirely, for example
+// h.Update(dbwrap.WithOptions(dbwrap.Options{Middlewares: mws})).
+func (h *Handle) Update(options ...Option) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	o := h.load().config
+	o.Middlewares = append([]Middleware(nil), o.Middlewares...)
+	o.Operations = append([]Operation(nil), o.Operations...)
+	o.filters = append([]middlewareFilter(nil), o.filters...)
+
+	for _, option := range options {
+		option(&o)
+	}
+
+	h.state.Store(h.prepare(o))
+}
+
+// Reset restores configuration of wrapper creation.
+func (h *Handle) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.state.Store(h.initial)
+}
+
+func (d wDriver) handle() *Handle {
+	return d.options.handle
+}
+
+func (c *wConn) handle() *Handle {
+	return c.options.handle
+}
+
+// state returns current runtime configuration.
+//
+// Operation loads it once, so that concurrent Update does not mix old and new configuration.
+func (o Options) state() *handleState {
+	if o.handle != nil {
+		return o.handle.load()
+	}
+
+	return &handleState{
+		middlewares: o.Middlewares,
+		filters:     o.filters,
+		intercept:   o.Intercept,
+		operations:  o.operations,
+	}
+}
+
+// enabled checks if operation should be wrapped with middlewares.
+func (s *handleState) enabled(operation Operation) bool {
+	return s.operations[operation]
+}
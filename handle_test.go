@@ -0,0 +1,168 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleOf(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		f    = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		mu   sync.Mutex
+		seen []string
+	)
+
+	mw := func(
+		ctx context.Context,
+		operation dbwrap.Operation,
+		statement string,
+		args []driver.NamedValue,
+	) (context.Context, func(error)) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		seen = append(seen, string(operation)+" "+statement)
+
+		return ctx, nil
+	}
+
+	assert.Nil(t, dbwrap.HandleOf(f.Connector()))
+	assert.Nil(t, dbwrap.HandleOf(dbwrap.WrapConnector(f.Connector())))
+	assert.NotNil(t, dbwrap.HandleOf(dbwrap.Wrap(f.Connector().Driver(), dbwrap.WithHandle())))
+
+	d, dh := dbwrap.WrapHandle(f.Connector().Driver())
+	assert.NotNil(t, dh)
+	assert.Equal(t, dh, dbwrap.HandleOf(d))
+
+	c, h := dbwrap.WrapConnectorHandle(f.Connector())
+	require.NotNil(t, h)
+	assert.Equal(t, h, dbwrap.HandleOf(c))
+
+	db := sql.OpenDB(c)
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err := db.ExecContext(ctx, "DELETE FROM a")
+	require.NoError(t, err)
+
+	h.Update(dbwrap.WithMiddleware(mw), dbwrap.WithOperations(dbwrap.Exec))
+	h.Update(dbwrap.WithInterceptor(func(
+		ctx context.Context,
+		operation dbwrap.Operation,
+		statement string,
+		args []driver.NamedValue,
+	) (context.Context, string, []driver.NamedValue) {
+		return ctx, statement + " -- x", args
+	}))
+
+	_, err = db.ExecContext(ctx, "DELETE FROM b")
+	require.NoError(t, err)
+
+	rows, err := db.QueryContext(ctx, "SELECT * FROM b")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	h.Reset()
+
+	_, err = db.ExecContext(ctx, "DELETE FROM c")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"exec DELETE FROM b -- x"}, seen)
+
+	var executed []string
+
+	for _, c := range f.Calls() {
+		if c.Method == "Conn.ExecContext" {
+			executed = append(executed, c.Statement)
+		}
+	}
+
+	assert.Equal(t, []string{"DELETE FROM a", "DELETE FROM b -- x", "DELETE FROM c"}, executed)
+
+	// Updates are safe for concurrent use with operations.
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			h.Update(dbwrap.WithMiddleware(mw))
+			h.Reset()
+		}()
+
+		_, err = db.ExecContext(ctx, "DELETE FROM d")
+		require.NoError(t, err)
+	}
+
+	wg.Wait()
+}
+
+func TestHandle_Update_inOperation(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		f    = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		h    *dbwrap.Handle
+		seen []string
+	)
+
+	mw := func(name string) dbwrap.Middleware {
+		return func(
+			ctx context.Context,
+			operation dbwrap.Operation,
+			statement string,
+			args []driver.NamedValue,
+		) (context.Context, func(error)) {
+			seen = append(seen, name+" "+statement)
+
+			return ctx, nil
+		}
+	}
+
+	c := dbwrap.WrapConnector(f.Connector(),
+		dbwrap.WithMiddleware(mw("old")),
+		dbwrap.WithOperations(dbwrap.Exec),
+		dbwrap.WithInterceptor(func(
+			ctx context.Context,
+			operation dbwrap.Operation,
+			statement string,
+			args []driver.NamedValue,
+		) (context.Context, string, []driver.NamedValue) {
+			// Update during operation applies to operations started afterwards.
+			h.Update(dbwrap.WithOptions(dbwrap.Options{
+				Middlewares: []dbwrap.Middleware{mw("new")},
+				Operations:  []dbwrap.Operation{dbwrap.Exec},
+			}))
+
+			return ctx, statement, args
+		}),
+	)
+	h = dbwrap.HandleOf(c)
+	require.NotNil(t, h)
+
+	db := sql.OpenDB(c)
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err := db.ExecContext(ctx, "DELETE FROM a")
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, "DELETE FROM b")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"old DELETE FROM a", "new DELETE FROM b"}, seen)
+}
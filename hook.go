@@ -0,0 +1,218 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// hook is an internal extension of operation processing.
+//
+// Unlike Middleware, hook wraps the driver call itself, so it can fail, serve or observe
+// results of the call. Functions that are nil are skipped.
+type hook struct {
+	// query wraps Query and StmtQuery driver calls.
+	query func(
+		ctx context.Context,
+		operation Operation,
+		statement string,
+		args []driver.NamedValue,
+		next func(ctx context.Context) (driver.Rows, error),
+	) (driver.Rows, error)
+
+	// exec wraps Exec and StmtExec driver calls.
+	exec func(
+		ctx context.Context,
+		operation Operation,
+		statement string,
+		args []driver.NamedValue,
+		next func(ctx context.Context) (driver.Result, error),
+	) (driver.Result, error)
+
+	// call wraps Ping, Prepare, Begin, Commit and Rollback driver calls.
+	call func(
+		ctx context.Context,
+		operation Operation,
+		statement string,
+		next func(ctx context.Context) error,
+	) error
+
+	// check runs for Exec, Query and Prepare of connection on the final statement after interceptor.
+	// It rejects statement with error or returns report that is called after operation, unless
+	// operation is skipped with driver.ErrSkip and database/sql retries it with Prepare.
+	check func(
+		ctx context.Context,
+		operation Operation,
+		statement string,
+		args []driver.NamedValue,
+	) (report func(), err error)
+
+	// abandon is called for transaction that ends without Commit or Rollback,
+	// because its connection is closed or reset.
+	abandon func(tx *txState)
+}
+
+// withHook adds a hook to a db wrapper.
+func withHook(h hook) Option {
+	return func(o *Options) {
+		o.hooks = append(o.hooks, h)
+	}
+}
+
+// query invokes f wrapped with query hooks, the first hook is the outermost.
+func (o Options) query(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+	f func(ctx context.Context) (driver.Rows, error),
+) (driver.Rows, error) {
+	if len(o.hooks) > 0 {
+		ctx = o.withOperation(ctx, operation, statement)
+	}
+
+	for i := len(o.hooks) - 1; i >= 0; i-- {
+		h := o.hooks[i].query
+		if h == nil {
+			continue
+		}
+
+		next := f
+		f = func(ctx context.Context) (driver.Rows, error) {
+			return h(ctx, operation, statement, args, next)
+		}
+	}
+
+	return f(ctx)
+}
+
+// exec invokes f wrapped with exec hooks, the first hook is the outermost.
+func (o Options) exec(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+	f func(ctx context.Context) (driver.Result, error),
+) (driver.Result, error) {
+	if len(o.hooks) > 0 {
+		ctx = o.withOperation(ctx, operation, statement)
+	}
+
+	for i := len(o.hooks) - 1; i >= 0; i-- {
+		h := o.hooks[i].exec
+		if h == nil {
+			continue
+		}
+
+		next := f
+		f = func(ctx context.Context) (driver.Result, error) {
+			return h(ctx, operation, statement, args, next)
+		}
+	}
+
+	res, err := f(ctx)
+
+	if p, ok := ctx.Value(statementCtxKey{}).(*opState); ok && p.operation == operation {
+		p.result = res
+	}
+
+	return res, err
+}
+
+// call invokes f wrapped with call hooks, the first hook is the outermost.
+func (o Options) call(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	f func(ctx context.Context) error,
+) error {
+	if len(o.hooks) > 0 {
+		ctx = o.withOperation(ctx, operation, statement)
+	}
+
+	for i := len(o.hooks) - 1; i >= 0; i-- {
+		h := o.hooks[i].call
+		if h == nil {
+			continue
+		}
+
+		next := f
+		f = func(ctx context.Context) error {
+			return h(ctx, operation, statement, next)
+		}
+	}
+
+	return f(ctx)
+}
+
+// check runs check hooks, done must be called with result of operation.
+func (o Options) check(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+) (done func(err error), err error) {
+	var reports []func()
+
+	if len(o.hooks) > 0 {
+		ctx = o.withOperation(ctx, operation, statement)
+	}
+
+	done = func(err error) {
+		if err == driver.ErrSkip {
+			return
+		}
+
+		for _, report := range reports {
+			report()
+		}
+	}
+
+	for _, h := range o.hooks {
+		if h.check == nil {
+			continue
+		}
+
+		report, err := h.check(ctx, operation, statement, args)
+		if err != nil {
+			done(err)
+
+			return nil, err
+		}
+
+		if report != nil {
+			reports = append(reports, report)
+		}
+	}
+
+	return done, nil
+}
+
+// abandonTx notifies hooks about transaction that ends without Commit or Rollback.
+func (o Options) abandonTx(tx *txState) {
+	for _, h := range o.hooks {
+		if h.abandon != nil {
+			h.abandon(tx)
+		}
+	}
+}
+
+// rowsDecorator overrides Next and Close of parent rows.
+type rowsDecorator struct {
+	wRows
+	next  func(dest []driver.Value) error
+	close func() error
+}
+
+func (r rowsDecorator) Next(dest []driver.Value) error {
+	return r.next(dest)
+}
+
+func (r rowsDecorator) Close() error {
+	return r.close()
+}
+
+// decorateRows returns driver.Rows with Next and Close replaced by given functions,
+// optional interfaces are delegated to parent.
+func decorateRows(parent driver.Rows, next func(dest []driver.Value) error, closeFn func() error) driver.Rows {
+	return composeRows(rowsDecorator{wRows: wRows{parent: parent}, next: next, close: closeFn}, parent)
+}
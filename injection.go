@@ -0,0 +1,230 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"sync"
+)
+
+// InjectionReason is a heuristic that flagged statement.
+type InjectionReason string
+
+// These constants enumerate injection heuristics.
+const (
+	// InjectionTautology is a condition that is always true, for example OR 1=1.
+	InjectionTautology = InjectionReason("tautology")
+
+	// InjectionStackedQueries is a statement followed by another one after semicolon.
+	InjectionStackedQueries = InjectionReason("stacked queries")
+
+	// InjectionCommentTruncation is a comment that cuts off the rest of statement.
+	InjectionCommentTruncation = InjectionReason("comment truncation")
+
+	// InjectionLiterals is a statement with literals that usually comes with arguments from the same caller.
+	InjectionLiterals = InjectionReason("literals instead of arguments")
+)
+
+// InjectionError describes a statement that looks like string-interpolated input.
+type InjectionError struct {
+	Operation Operation
+	Statement string
+	Caller    string
+	Reasons   []InjectionReason
+}
+
+// Error implements error.
+func (e *InjectionError) Error() string {
+	reasons := make([]string, 0, len(e.Reasons))
+
+	for _, r := range e.Reasons {
+		reasons = append(reasons, string(r))
+	}
+
+	return "dbwrap: suspected SQL injection (" + strings.Join(reasons, ", ") + ") from " + e.Caller
+}
+
+// InjectionConfig controls InjectionDetector.
+type InjectionConfig struct {
+	// OnSuspect is called for flagged statements.
+	OnSuspect func(ctx context.Context, err *InjectionError)
+
+	// Block rejects flagged statements with *InjectionError.
+	Block bool
+
+	// CallerSkip lists packages to skip when detecting caller, see CallerCtx.
+	CallerSkip []string
+
+	// MaxTracked limits number of tracked fingerprints with callers for InjectionLiterals, default 10000.
+	MaxTracked int
+}
+
+// InjectionDetector flags Exec, Query and Prepare statements that look like string-interpolated input.
+//
+// Heuristics may have false positives, so blocking mode is better enabled after monitoring.
+type InjectionDetector struct {
+	config InjectionConfig
+
+	mu            sync.Mutex
+	parameterized map[string]bool
+}
+
+// NewInjectionDetector creates InjectionDetector.
+func NewInjectionDetector(config InjectionConfig) *InjectionDetector {
+	if config.MaxTracked == 0 {
+		config.MaxTracked = 10000
+	}
+
+	return &InjectionDetector{
+		config:        config,
+		parameterized: make(map[string]bool),
+	}
+}
+
+// WithInjectionDetector adds injection detector to a db wrapper.
+//
+// Statements are checked after statement interceptor, as they are sent to driver.
+// Operation that driver skips with driver.ErrSkip is not reported, as it is retried with Prepare.
+func WithInjectionDetector(d *InjectionDetector) Option {
+	return withHook(hook{
+		check: d.check,
+	})
+}
+
+// check flags final statement of operation, it returns error in blocking mode.
+func (d *InjectionDetector) check(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+) (func(), error) {
+	reasons, literals, placeholders := injectionReasons(parseStatement(ctx, statement).lex())
+	caller := CallerCtx(ctx, d.config.CallerSkip...)
+	hasArgs := len(args) > 0
+
+	if literals || placeholders || hasArgs {
+		key := Fingerprint(statement) + "\x00" + caller
+
+		d.mu.Lock()
+
+		if placeholders || hasArgs {
+			if len(d.parameterized) < d.config.MaxTracked {
+				d.parameterized[key] = true
+			}
+		} else if d.parameterized[key] {
+			reasons = append(reasons, InjectionLiterals)
+		}
+
+		d.mu.Unlock()
+	}
+
+	if len(reasons) == 0 {
+		return nil, nil
+	}
+
+	err := &InjectionError{Operation: operation, Statement: statement, Caller: caller, Reasons: reasons}
+	report := func() {
+		if d.config.OnSuspect != nil {
+			d.config.OnSuspect(ctx, err)
+		}
+	}
+
+	if d.config.Block {
+		report()
+
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// injectionReasons applies heuristics to tokens, it also reports presence of literals and placeholders.
+func injectionReasons(tokens []sqlToken) (reasons []InjectionReason, literals, placeholders bool) {
+	var tautology, stacked, truncation bool
+
+	for i, t := range tokens {
+		switch t.kind {
+		case sqlString, sqlNumber:
+			literals = true
+		case sqlPlaceholder:
+			placeholders = true
+		case sqlComment:
+			if isTruncation(t.text) {
+				truncation = true
+			}
+		case sqlPunct:
+			if t.text == ";" && len(significant(tokens[i+1:])) > 0 {
+				stacked = true
+			}
+		case sqlWord:
+			if t.keyword() == "OR" && isTautology(significant(tokens[i+1:])) {
+				tautology = true
+			}
+		}
+	}
+
+	if tautology {
+		reasons = append(reasons, InjectionTautology)
+	}
+
+	if stacked {
+		reasons = append(reasons, InjectionStackedQueries)
+	}
+
+	if truncation {
+		reasons = append(reasons, InjectionCommentTruncation)
+	}
+
+	return reasons, literals, placeholders
+}
+
+// isTruncation checks if comment cuts off the rest of statement.
+//
+// Unterminated block comments, line comments that start with an unbalanced quote, like in 'admin'--',
+// and line comments that start with a condition, like in id = 1 -- AND tenant = 2, are suspicious.
+// Other comments, for example sqlcommenter tags or apostrophes in text, are not.
+func isTruncation(comment string) bool {
+	if strings.HasPrefix(comment, "/*") {
+		return !strings.HasSuffix(comment, "*/") || len(comment) < 4
+	}
+
+	body := strings.TrimLeft(strings.TrimPrefix(comment, "--"), " \t")
+	if body == "" {
+		return false
+	}
+
+	if c := body[0]; c == '\'' || c == '"' || c == '`' {
+		_, ok := quoteEnd(body, 0, c, false)
+
+		return !ok
+	}
+
+	tokens := significant(lexSQL(body))
+
+	return len(tokens) > 0 && (tokens[0].keyword() == "AND" || tokens[0].keyword() == "OR")
+}
+
+// isTautology checks if tokens start with an always true condition,
+// for example 1=1, 'a'='a', TRUE or a non-zero number.
+func isTautology(tokens []sqlToken) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+
+	first := tokens[0]
+	isLiteral := first.kind == sqlString || first.kind == sqlNumber
+
+	if len(tokens) >= 3 && isLiteral && tokens[1].kind == sqlPunct && tokens[2].kind == first.kind {
+		switch tokens[1].text {
+		case "=", "==", ">=", "<=":
+			return tokens[2].text == first.text
+		case "<>", "!=":
+			return tokens[2].text != first.text
+		}
+	}
+
+	end := len(tokens) == 1 || tokens[1].is(")") || tokens[1].is(";") || tokens[1].keyword() == "OR" ||
+		tokens[1].keyword() == "AND"
+
+	return end && (first.keyword() == "TRUE" || (first.kind == sqlNumber && strings.Trim(first.text, "0.") != ""))
+}
@@ -0,0 +1,190 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithInjectionDetector(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		f        = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		suspects []*dbwrap.InjectionError
+	)
+
+	d := dbwrap.NewInjectionDetector(dbwrap.InjectionConfig{
+		OnSuspect: func(ctx context.Context, err *dbwrap.InjectionError) {
+			suspects = append(suspects, err)
+		},
+	})
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithInjectionDetector(d)))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	for _, s := range []string{
+		"SELECT a FROM t WHERE b = 'x' -- caller",
+		"SELECT a FROM t WHERE b = 1 OR 1 = 1",
+		"SELECT a FROM t WHERE b = 'x' OR 'a'='a'",
+		"SELECT a FROM t WHERE b = 1; DROP TABLE t",
+		"SELECT a FROM t WHERE b = 'admin'--' AND c = 1",
+		"SELECT a FROM t WHERE b = 1 OR TRUE",
+	} {
+		rows, err := db.QueryContext(ctx, s)
+		require.NoError(t, err)
+		require.NoError(t, rows.Close())
+	}
+
+	// Parameterized statement from the same caller makes literals suspicious.
+	_, err := db.ExecContext(ctx, "DELETE FROM t WHERE id = ?", 1)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "DELETE FROM t WHERE id = 123")
+	require.NoError(t, err)
+
+	var reasons [][]dbwrap.InjectionReason
+
+	for _, s := range suspects {
+		reasons = append(reasons, s.Reasons)
+	}
+
+	assert.Equal(t, [][]dbwrap.InjectionReason{
+		{dbwrap.InjectionTautology},
+		{dbwrap.InjectionTautology},
+		{dbwrap.InjectionStackedQueries},
+		{dbwrap.InjectionCommentTruncation},
+		{dbwrap.InjectionTautology},
+		{dbwrap.InjectionLiterals},
+	}, reasons)
+	assert.Equal(t, "bool64/dbwrap_test.TestWithInjectionDetector", suspects[0].Caller)
+
+	d = dbwrap.NewInjectionDetector(dbwrap.InjectionConfig{Block: true})
+	db = sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithInjectionDetector(d)))
+
+	_, err = db.ExecContext(ctx, "DELETE FROM t WHERE b = '' OR 1=1")
+
+	_, ok := err.(*dbwrap.InjectionError)
+	require.True(t, ok)
+	assert.EqualError(t, err, "dbwrap: suspected SQL injection (tautology) from bool64/dbwrap_test.TestWithInjectionDetector")
+	require.NoError(t, db.Close())
+}
+
+func TestWithInjectionDetector_comments(t *testing.T) {
+	var (
+		ctx = context.Background()
+		f   = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		d   = dbwrap.NewInjectionDetector(dbwrap.InjectionConfig{Block: true})
+		db  = sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithInjectionDetector(d)))
+	)
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	for _, s := range []string{
+		"SELECT a FROM t WHERE b = ? /*controller='users',action='show'*/",
+		"/*traceparent='00-5bd66ef5095369c7b0d1f8f4bd33716a-c532cb4098ac3dd2-01'*/ SELECT a FROM t",
+		"SELECT a FROM t WHERE b = ? -- don't cache",
+		"SELECT a FROM t WHERE b = ? -- \"quoted\" note, a = b",
+	} {
+		rows, err := db.QueryContext(ctx, s, 1)
+		require.NoError(t, err, s)
+		require.NoError(t, rows.Close())
+	}
+
+	for _, s := range []string{
+		"SELECT a FROM t WHERE b = 'admin'--' AND c = ?",
+		"SELECT a FROM t WHERE b = ? -- AND tenant = 2",
+		"SELECT a FROM t WHERE b = ? /* AND tenant = 2",
+	} {
+		_, err := db.QueryContext(ctx, s, 1)
+
+		ie, ok := err.(*dbwrap.InjectionError)
+		require.True(t, ok, s)
+		assert.Equal(t, []dbwrap.InjectionReason{dbwrap.InjectionCommentTruncation}, ie.Reasons, s)
+	}
+}
+
+func TestWithInjectionDetector_skip(t *testing.T) {
+	ctx := context.Background()
+
+	caps := dbwraptest.AllCapabilities()
+	caps.ExecerContext = false
+	caps.Execer = false
+
+	skipped := false
+	skipping := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	skipping.Exec = func(ctx context.Context, statement string, args []driver.NamedValue) (driver.Result, error) {
+		// Conn.ExecContext is skipped, Stmt.ExecContext is not.
+		if !skipped {
+			skipped = true
+
+			return nil, driver.ErrSkip
+		}
+
+		return driver.RowsAffected(0), nil
+	}
+
+	for name, f := range map[string]*dbwraptest.Fake{
+		"no execer": dbwraptest.NewFake(caps),
+		"skip":      skipping,
+	} {
+		f := f
+
+		t.Run(name, func(t *testing.T) {
+			var operations []dbwrap.Operation
+
+			d := dbwrap.NewInjectionDetector(dbwrap.InjectionConfig{
+				OnSuspect: func(ctx context.Context, err *dbwrap.InjectionError) {
+					operations = append(operations, err.Operation)
+				},
+			})
+
+			db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithInjectionDetector(d)))
+
+			_, err := db.ExecContext(ctx, "DELETE FROM t WHERE b = '' OR 1=1")
+			require.NoError(t, err)
+			require.NoError(t, db.Close())
+
+			// Statement is reported once, by Prepare that follows driver.ErrSkip of Exec.
+			assert.Equal(t, []dbwrap.Operation{dbwrap.Prepare}, operations)
+		})
+	}
+}
+
+func TestWithInjectionDetector_intercept(t *testing.T) {
+	var (
+		ctx = context.Background()
+		f   = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		d   = dbwrap.NewInjectionDetector(dbwrap.InjectionConfig{Block: true})
+		db  = sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithInjectionDetector(d),
+			dbwrap.WithInterceptor(func(
+				ctx context.Context,
+				operation dbwrap.Operation,
+				statement string,
+				args []driver.NamedValue,
+			) (context.Context, string, []driver.NamedValue) {
+				return ctx, statement + " OR 1=1", args
+			})))
+	)
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	// Final statement of interceptor is checked.
+	_, err := db.ExecContext(ctx, "DELETE FROM t WHERE b = ?", 1)
+
+	ie, ok := err.(*dbwrap.InjectionError)
+	require.True(t, ok)
+	assert.Equal(t, "DELETE FROM t WHERE b = ? OR 1=1", ie.Statement)
+	assert.NotContains(t, f.Methods(), "Conn.ExecContext")
+}
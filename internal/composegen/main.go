@@ -0,0 +1,331 @@
+// Package main generates composition of optional interfaces.
+//
+// A value that implements all optional interfaces of database/sql/driver
+// can be exposed with any subset of them by embedding it into an anonymous struct
+// with the chosen interfaces. This tool emits such structs for every combination,
+// so that supporting a new interface only requires adding it to a list.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+// optional describes an optional interface.
+type optional struct {
+	// Iface is a qualified name of interface.
+	Iface string
+	// Flag is an expression that enables interface.
+	Flag string
+	// Assert is a type assertion expression that enables interface if it succeeds, it is used instead of Flag.
+	Assert string
+	// Value is an expression that implements interface.
+	Value string
+	// Methods declare interface Iface in generated file, it is needed for interfaces
+	// that embed the base interface and so can not be composed without ambiguity,
+	// for interfaces with a method named as interface, that would be shadowed by embedded field,
+	// and for interfaces that are not available in minimal supported Go version.
+	Methods []string
+}
+
+// group describes a composition function.
+type group struct {
+	// Func is a name of generated function.
+	Func string
+	// Params are parameters of generated function.
+	Params string
+	// Base is a mandatory interface, also a result type.
+	Base string
+	// BaseValue is an expression that implements Base.
+	BaseValue string
+	// Optional lists optional interfaces.
+	Optional []optional
+}
+
+// target describes generated file.
+type target struct {
+	// BuildTag is an optional build constraint of generated file.
+	BuildTag string
+	Package  string
+	Imports  []string
+	Groups   []group
+}
+
+var targets = map[string]target{
+	"dbwrap": {
+		BuildTag: "go1.10",
+		Package:  "dbwrap",
+		Imports:  []string{"database/sql/driver"},
+		Groups: []group{
+			{
+				Func:      "composeConn",
+				Params:    "c *wConn, parent driver.Conn",
+				Base:      "conn",
+				BaseValue: "c",
+				Optional: []optional{
+					{
+						Iface: "driver.NamedValueChecker", Assert: "parent.(driver.NamedValueChecker)",
+						Value: "parent.(driver.NamedValueChecker)",
+					},
+					{Iface: "driver.SessionResetter", Flag: "resetsSession(parent, c.options)", Value: "c"},
+					{
+						Iface: "withValidator", Assert: "parent.(withValidator)", Value: "parent.(withValidator)",
+						Methods: []string{"IsValid() bool"},
+					},
+				},
+			},
+			{
+				Func:      "composeStmt",
+				Params:    "s wStmt, parent driver.Stmt",
+				Base:      "driver.Stmt",
+				BaseValue: "s",
+				Optional: []optional{
+					{Iface: "driver.StmtExecContext", Assert: "parent.(driver.StmtExecContext)", Value: "s"},
+					{Iface: "driver.StmtQueryContext", Assert: "parent.(driver.StmtQueryContext)", Value: "s"},
+					{
+						Iface: "withColumnConverter", Assert: "parent.(driver.ColumnConverter)",
+						Value: "parent.(withColumnConverter)",
+					},
+					{
+						Iface: "driver.NamedValueChecker", Assert: "parent.(driver.NamedValueChecker)",
+						Value: "parent.(driver.NamedValueChecker)",
+					},
+				},
+			},
+		},
+	},
+	"dbwrap-rows": {
+		Package: "dbwrap",
+		Imports: []string{"database/sql/driver", "reflect"},
+		Groups: []group{
+			{
+				Func:      "composeRows",
+				Params:    "r driver.Rows, parent driver.Rows",
+				Base:      "driver.Rows",
+				BaseValue: "r",
+				Optional: []optional{
+					{
+						Iface: "withRowsNextResultSet", Assert: "parent.(driver.RowsNextResultSet)",
+						Value:   "parent.(withRowsNextResultSet)",
+						Methods: []string{"HasNextResultSet() bool", "NextResultSet() error"},
+					},
+					{
+						Iface: "withRowsColumnTypeScanType", Assert: "parent.(driver.RowsColumnTypeScanType)",
+						Value:   "parent.(withRowsColumnTypeScanType)",
+						Methods: []string{"ColumnTypeScanType(index int) reflect.Type"},
+					},
+					{
+						Iface: "withRowsColumnTypeDatabaseTypeName", Assert: "parent.(driver.RowsColumnTypeDatabaseTypeName)",
+						Value:   "parent.(withRowsColumnTypeDatabaseTypeName)",
+						Methods: []string{"ColumnTypeDatabaseTypeName(index int) string"},
+					},
+					{
+						Iface: "withRowsColumnTypeLength", Assert: "parent.(driver.RowsColumnTypeLength)",
+						Value:   "parent.(withRowsColumnTypeLength)",
+						Methods: []string{"ColumnTypeLength(index int) (length int64, ok bool)"},
+					},
+					{
+						Iface: "withRowsColumnTypeNullable", Assert: "parent.(driver.RowsColumnTypeNullable)",
+						Value:   "parent.(withRowsColumnTypeNullable)",
+						Methods: []string{"ColumnTypeNullable(index int) (nullable, ok bool)"},
+					},
+					{
+						Iface: "withRowsColumnTypePrecisionScale", Assert: "parent.(driver.RowsColumnTypePrecisionScale)",
+						Value:   "parent.(withRowsColumnTypePrecisionScale)",
+						Methods: []string{"ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool)"},
+					},
+				},
+			},
+		},
+	},
+	"dbwraptest": {
+		Package: "dbwraptest",
+		Imports: []string{"database/sql/driver", "reflect"},
+		Groups: []group{
+			{
+				Func:      "composeDriver",
+				Params:    "d *fakeDriver, caps Capabilities",
+				Base:      "driver.Driver",
+				BaseValue: "d",
+				Optional: []optional{
+					{Iface: "driver.DriverContext", Flag: "caps.DriverContext", Value: "d"},
+				},
+			},
+			{
+				Func:      "composeConn",
+				Params:    "c *conn, caps Capabilities",
+				Base:      "fakeConn",
+				BaseValue: "c",
+				Optional: []optional{
+					{Iface: "driver.ExecerContext", Flag: "caps.ExecerContext", Value: "c"},
+					{Iface: "driver.QueryerContext", Flag: "caps.QueryerContext", Value: "c"},
+					{Iface: "driver.NamedValueChecker", Flag: "caps.ConnNamedValueChecker", Value: "c"},
+					{Iface: "driver.SessionResetter", Flag: "caps.SessionResetter", Value: "c"},
+					{
+						Iface: "withValidator", Flag: "caps.Validator", Value: "c",
+						Methods: []string{"IsValid() bool"},
+					},
+				},
+			},
+			{
+				Func:      "composeStmt",
+				Params:    "s *stmt, caps Capabilities",
+				Base:      "driver.Stmt",
+				BaseValue: "s",
+				Optional: []optional{
+					{Iface: "driver.StmtExecContext", Flag: "caps.StmtExecContext", Value: "s"},
+					{Iface: "driver.StmtQueryContext", Flag: "caps.StmtQueryContext", Value: "s"},
+					{
+						Iface: "withColumnConverter", Flag: "caps.ColumnConverter", Value: "s",
+						Methods: []string{"ColumnConverter(idx int) driver.ValueConverter"},
+					},
+					{Iface: "driver.NamedValueChecker", Flag: "caps.StmtNamedValueChecker", Value: "s"},
+				},
+			},
+			{
+				Func:      "composeRows",
+				Params:    "r *rows, caps Capabilities",
+				Base:      "driver.Rows",
+				BaseValue: "r",
+				Optional: []optional{
+					{
+						Iface: "withRowsNextResultSet", Flag: "caps.RowsNextResultSet", Value: "r",
+						Methods: []string{"HasNextResultSet() bool", "NextResultSet() error"},
+					},
+					{
+						Iface: "withRowsColumnTypeScanType", Flag: "caps.RowsColumnTypeScanType", Value: "r",
+						Methods: []string{"ColumnTypeScanType(index int) reflect.Type"},
+					},
+					{
+						Iface: "withRowsColumnTypeDatabaseTypeName", Flag: "caps.RowsColumnTypeDatabaseTypeName", Value: "r",
+						Methods: []string{"ColumnTypeDatabaseTypeName(index int) string"},
+					},
+					{
+						Iface: "withRowsColumnTypeLength", Flag: "caps.RowsColumnTypeLength", Value: "r",
+						Methods: []string{"ColumnTypeLength(index int) (length int64, ok bool)"},
+					},
+					{
+						Iface: "withRowsColumnTypeNullable", Flag: "caps.RowsColumnTypeNullable", Value: "r",
+						Methods: []string{"ColumnTypeNullable(index int) (nullable, ok bool)"},
+					},
+					{
+						Iface: "withRowsColumnTypePrecisionScale", Flag: "caps.RowsColumnTypePrecisionScale", Value: "r",
+						Methods: []string{"ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool)"},
+					},
+				},
+			},
+		},
+	},
+}
+
+func main() {
+	var (
+		name = flag.String("target", "", "target to generate")
+		out  = flag.String("out", "", "output file")
+	)
+
+	flag.Parse()
+
+	t, ok := targets[*name]
+	if !ok || *out == "" {
+		log.Fatalf("usage: composegen -target <name> -out <file>, unknown target %q", *name)
+	}
+
+	src, err := generate(t)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(*out, src, 0600); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate(t target) ([]byte, error) {
+	b := bytes.NewBuffer(nil)
+
+	b.WriteString("// Code generated by internal/composegen. DO NOT EDIT.\n\n")
+
+	if t.BuildTag != "" {
+		b.WriteString("//go:build " + t.BuildTag + "\n// +build " + t.BuildTag + "\n\n")
+	}
+
+	b.WriteString("package " + t.Package + "\n\nimport (\n")
+
+	for _, i := range t.Imports {
+		b.WriteString("\t\"" + i + "\"\n")
+	}
+
+	b.WriteString(")\n")
+
+	for _, g := range t.Groups {
+		writeInterfaces(b, g)
+		writeGroup(b, g)
+	}
+
+	return format.Source(b.Bytes())
+}
+
+func writeInterfaces(b *bytes.Buffer, g group) {
+	for _, o := range g.Optional {
+		if len(o.Methods) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(b, "\n// %s is a method set of %s that can be embedded with %s.\n", o.Iface,
+			"driver."+strings.TrimPrefix(o.Iface, "with"), g.Base)
+		fmt.Fprintf(b, "type %s interface {\n\t%s\n}\n", o.Iface, strings.Join(o.Methods, "\n\t"))
+	}
+}
+
+func writeGroup(b *bytes.Buffer, g group) {
+	n := len(g.Optional)
+
+	fmt.Fprintf(b, "\n// %s returns %s that implements optional interfaces enabled by flags.\n", g.Func, g.Base)
+	fmt.Fprintf(b, "//\n//nolint:funlen,gocyclo,maintidx // Generated code.\n")
+	fmt.Fprintf(b, "func %s(%s) %s {\n", g.Func, g.Params, g.Base)
+	b.WriteString("\tmask := 0\n\n")
+
+	for i, o := range g.Optional {
+		if o.Assert != "" {
+			fmt.Fprintf(b, "\tif _, ok := %s; ok {\n\t\tmask |= 1 << %d\n\t}\n\n", o.Assert, i)
+
+			continue
+		}
+
+		fmt.Fprintf(b, "\tif %s {\n\t\tmask |= 1 << %d\n\t}\n\n", o.Flag, i)
+	}
+
+	b.WriteString("\tswitch mask {\n")
+
+	for mask := 0; mask < 1<<uint(n); mask++ {
+		var (
+			fields = []string{g.Base}
+			values = []string{g.BaseValue}
+			names  []string
+		)
+
+		for i, o := range g.Optional {
+			if mask&(1<<uint(i)) != 0 {
+				fields = append(fields, o.Iface)
+				values = append(values, o.Value)
+				names = append(names, strings.TrimPrefix(strings.TrimPrefix(o.Iface, "driver."), "with"))
+			}
+		}
+
+		comment := "none"
+		if len(names) > 0 {
+			comment = strings.Join(names, ", ")
+		}
+
+		fmt.Fprintf(b, "\tcase %d: // %s\n", mask, comment)
+		fmt.Fprintf(b, "\t\treturn struct {\n\t\t\t%s\n\t\t}{%s}\n", strings.Join(fields, "\n\t\t\t"), strings.Join(values, ", "))
+	}
+
+	b.WriteString("\t}\n\n\tpanic(\"unreachable\")\n}\n")
+}
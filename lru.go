@@ -0,0 +1,88 @@
+package dbwrap
+
+import (
+	"container/list"
+)
+
+// lru is a fixed capacity map with least recently used eviction, it is not safe for concurrent use.
+type lru struct {
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+
+	// onEvict is called for removed values if not nil.
+	onEvict func(key string, value interface{})
+}
+
+type lruItem struct {
+	key   string
+	value interface{}
+}
+
+func newLRU(capacity int, onEvict func(key string, value interface{})) *lru {
+	return &lru{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		onEvict:  onEvict,
+	}
+}
+
+func (l *lru) get(key string) (interface{}, bool) {
+	e, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	l.order.MoveToFront(e)
+
+	return e.Value.(*lruItem).value, true
+}
+
+func (l *lru) set(key string, value interface{}) {
+	if e, ok := l.items[key]; ok {
+		l.order.MoveToFront(e)
+
+		item := e.Value.(*lruItem)
+		old := item.value
+		item.value = value
+
+		if l.onEvict != nil {
+			l.onEvict(key, old)
+		}
+
+		return
+	}
+
+	l.items[key] = l.order.PushFront(&lruItem{key: key, value: value})
+
+	for l.capacity > 0 && l.order.Len() > l.capacity {
+		l.remove(l.order.Back())
+	}
+}
+
+func (l *lru) delete(key string) {
+	if e, ok := l.items[key]; ok {
+		l.remove(e)
+	}
+}
+
+// purge removes all values.
+func (l *lru) purge() {
+	for l.order.Len() > 0 {
+		l.remove(l.order.Back())
+	}
+}
+
+func (l *lru) len() int {
+	return l.order.Len()
+}
+
+func (l *lru) remove(e *list.Element) {
+	item := l.order.Remove(e).(*lruItem)
+	delete(l.items, item.key)
+
+	if l.onEvict != nil {
+		l.onEvict(item.key, item.value)
+	}
+}
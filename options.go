@@ -39,6 +39,39 @@ type Options struct {
 	Operations []Operation
 
 	operations map[Operation]bool
+
+	// filters limit middlewares with the same index, they may be shorter than Middlewares.
+	filters []middlewareFilter
+
+	// hooks wrap driver calls.
+	hooks []hook
+
+	// observer receives errors of driver calls, it is set for connections of FailoverConnector.
+	observer connObserver
+
+	// readOnly restricts operations to reads.
+	readOnly readOnlyMode
+
+	// stmtCacheSize is a capacity of prepared statements cache of connection.
+	stmtCacheSize int
+
+	// placeholders is a style of rewritten placeholders.
+	placeholders PlaceholderStyle
+
+	// tenants routes operations to schema of tenant.
+	tenants *TenantConfig
+
+	// callerSkip lists package patterns to skip when detecting caller of operation.
+	callerSkip []string
+
+	// conn is a connection of operations, it is set by newConn.
+	conn *wConn
+
+	// handle holds runtime configuration of wrapper.
+	handle *Handle
+
+	// withHandle makes wrapper operational for HandleOf.
+	withHandle bool
 }
 
 // WithOptions sets our wrapper options through a single
@@ -71,6 +104,32 @@ func WithInterceptor(i func(
 	}
 }
 
+// interceptor is a signature of Options.Intercept.
+type interceptor = func(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+) (context.Context, string, []driver.NamedValue)
+
+// chainInterceptors returns interceptor that calls first and then next, next may be nil.
+func chainInterceptors(first, next interceptor) interceptor {
+	if next == nil {
+		return first
+	}
+
+	return func(
+		ctx context.Context,
+		operation Operation,
+		statement string,
+		args []driver.NamedValue,
+	) (context.Context, string, []driver.NamedValue) {
+		ctx, statement, args = first(ctx, operation, statement, args)
+
+		return next(ctx, operation, statement, args)
+	}
+}
+
 // WithOperations controls which operations should be wrapped with middlewares.
 // It does not affect statement interceptor.
 func WithOperations(op ...Operation) Option {
@@ -97,6 +156,8 @@ func WithAllOperations() Option {
 		RowsNext,
 		Commit,
 		Rollback,
+		StmtCacheHit,
+		StmtCacheMiss,
 	)
 }
 
@@ -108,10 +169,23 @@ func prepareOptions(options []Option) (Options, bool) {
 		option(&o)
 	}
 
-	if len(o.Middlewares) == 0 && o.Intercept == nil {
+	if len(o.Middlewares) == 0 && o.Intercept == nil && len(o.hooks) == 0 && o.stmtCacheSize == 0 &&
+		o.placeholders == 0 && o.tenants == nil && !o.withHandle {
 		return o, false
 	}
 
+	o.handle = newHandle(o)
+	o.prepare()
+
+	return o, true
+}
+
+// prepare chains built-in interceptors and builds operations.
+func (o *Options) prepare() {
+	if o.tenants != nil && o.tenants.Mode == TenantQualify {
+		o.Intercept = chainInterceptors(o.tenants.intercept, o.Intercept)
+	}
+
 	o.operations = defaultOperations
 
 	if len(o.Operations) > 0 {
@@ -122,5 +196,46 @@ func prepareOptions(options []Option) (Options, bool) {
 		}
 	}
 
-	return o, true
+	o.enableFiltered()
+}
+
+// enableFiltered enables operations of WithMiddlewareFor,
+// other middlewares are limited to operations of Options.
+func (o *Options) enableFiltered() {
+	base := o.operations
+	extended := false
+
+	for _, f := range o.filters {
+		for op := range f.operations {
+			if base[op] {
+				continue
+			}
+
+			if !extended {
+				o.operations = make(map[Operation]bool, len(base))
+
+				for op := range base {
+					o.operations[op] = true
+				}
+
+				extended = true
+			}
+
+			o.operations[op] = true
+		}
+	}
+
+	if !extended {
+		return
+	}
+
+	for len(o.filters) < len(o.Middlewares) {
+		o.filters = append(o.filters, middlewareFilter{})
+	}
+
+	for i, f := range o.filters {
+		if f.operations == nil {
+			o.filters[i].operations = base
+		}
+	}
 }
@@ -0,0 +1,314 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PlaceholderStyle is a syntax of argument placeholders.
+type PlaceholderStyle int
+
+// These constants enumerate placeholder styles.
+const (
+	// PlaceholderQuestion is a positional "?" of MySQL and SQLite.
+	PlaceholderQuestion = PlaceholderStyle(iota + 1)
+
+	// PlaceholderDollar is a numbered "$1" of PostgreSQL.
+	PlaceholderDollar
+
+	// PlaceholderColon is a named ":name" of Oracle and SQLite.
+	PlaceholderColon
+)
+
+// WithPlaceholders rewrites placeholders of statements and arguments to style, see RewritePlaceholders.
+//
+// Rewriting happens before statement interceptor, so that interceptor and middlewares receive
+// statements and arguments as they are sent to driver. Operation fails without driver call
+// if arguments do not match placeholders.
+func WithPlaceholders(style PlaceholderStyle) Option {
+	return func(o *Options) {
+		o.placeholders = style
+	}
+}
+
+type placeholderSourceCtxKey struct{}
+
+// rewrite rewrites placeholders of connection operations and keeps source statement of Prepare in context.
+func (p PlaceholderStyle) rewrite(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+) (context.Context, string, []driver.NamedValue, error) {
+	switch operation {
+	case Exec, Query:
+		// Statement without arguments has no placeholders, but may have PostgreSQL jsonb operator "?".
+		if len(args) == 0 {
+			return ctx, statement, args, nil
+		}
+	case Prepare:
+		ctx = context.WithValue(ctx, placeholderSourceCtxKey{}, statement)
+	}
+
+	statement, args, err := RewritePlaceholders(statement, args, p)
+
+	return ctx, statement, args, err
+}
+
+// stmtArgs rewrites arguments of prepared statement using source statement from prepare context.
+func (p PlaceholderStyle) stmtArgs(prepareCtx context.Context, args []driver.NamedValue) ([]driver.NamedValue, error) {
+	source, ok := prepareCtx.Value(placeholderSourceCtxKey{}).(string)
+	if !ok {
+		return args, nil
+	}
+
+	_, args, err := RewritePlaceholders(source, args, p)
+
+	return args, err
+}
+
+// placeholderRef is a placeholder occurrence in statement.
+type placeholderRef struct {
+	start, end int
+	ordinal    int
+	name       string
+	style      PlaceholderStyle
+}
+
+// key identifies argument of placeholder.
+func (r placeholderRef) key() string {
+	if r.name != "" {
+		return ":" + r.name
+	}
+
+	return "$" + strconv.Itoa(r.ordinal)
+}
+
+// RewritePlaceholders converts placeholders ("?", "$1" or ":name") of statement to style and adjusts args.
+//
+// String literals, quoted identifiers and comments are not changed. Named args become positional
+// for PlaceholderQuestion and PlaceholderDollar, positional args get names "p1", "p2", ... for PlaceholderColon.
+// Ordinals of resulting args follow placeholders of resulting statement.
+//
+// If args are empty, only statement is rewritten. If args do not match placeholders, error is returned
+// with statement and args unchanged, args are also validated for statement that already has style.
+//
+// PostgreSQL jsonb operators "?|" and "?&" are not placeholders, neither is "?" in statement with "$1" or
+// ":name" placeholders. WithPlaceholders does not rewrite Exec and Query without args to keep operator "?".
+// Slices of PostgreSQL arrays, like "a[1:n]", are not named placeholders.
+func RewritePlaceholders(
+	statement string,
+	args []driver.NamedValue,
+	style PlaceholderStyle,
+) (string, []driver.NamedValue, error) {
+	var (
+		refs      = placeholderRefs(statement)
+		converted = true
+		keys      = map[string]int{}
+		source    []placeholderRef
+		numbers   = make([]int, len(refs))
+	)
+
+	for i, r := range refs {
+		if r.style != style {
+			converted = false
+		}
+
+		if style == PlaceholderQuestion {
+			source = append(source, r)
+			numbers[i] = len(source)
+
+			continue
+		}
+
+		n, seen := keys[r.key()]
+		if !seen {
+			source = append(source, r)
+			n = len(source)
+			keys[r.key()] = n
+		}
+
+		numbers[i] = n
+	}
+
+	var res []driver.NamedValue
+
+	if len(args) > 0 {
+		var err error
+
+		if res, err = placeholderArgs(statement, source, args, style); err != nil {
+			return statement, args, err
+		}
+	}
+
+	// Statement is left as is and args are only validated.
+	if converted {
+		return statement, args, nil
+	}
+
+	var (
+		b   strings.Builder
+		pos int
+	)
+
+	for i, r := range refs {
+		b.WriteString(statement[pos:r.start])
+		pos = r.end
+
+		switch style {
+		case PlaceholderQuestion:
+			b.WriteByte('?')
+		case PlaceholderDollar:
+			b.WriteString("$" + strconv.Itoa(numbers[i]))
+		default:
+			b.WriteString(":" + placeholderName(r))
+		}
+	}
+
+	b.WriteString(statement[pos:])
+
+	if len(args) == 0 {
+		return b.String(), args, nil
+	}
+
+	return b.String(), res, nil
+}
+
+// placeholderArgs returns args in order of source placeholders, every arg must match a placeholder.
+func placeholderArgs(
+	statement string,
+	source []placeholderRef,
+	args []driver.NamedValue,
+	style PlaceholderStyle,
+) ([]driver.NamedValue, error) {
+	var (
+		res  = make([]driver.NamedValue, 0, len(source))
+		used = make([]bool, len(args))
+	)
+
+	for i, r := range source {
+		j := findArg(args, r)
+		if j < 0 {
+			return nil, fmt.Errorf("dbwrap: missing argument for placeholder %s", statement[r.start:r.end])
+		}
+
+		used[j] = true
+		a := args[j]
+		a.Ordinal = i + 1
+		a.Name = ""
+
+		if style == PlaceholderColon {
+			a.Name = placeholderName(r)
+		}
+
+		res = append(res, a)
+	}
+
+	for j, u := range used {
+		if !u {
+			return nil, fmt.Errorf("dbwrap: unused argument %s", argName(args[j]))
+		}
+	}
+
+	return res, nil
+}
+
+func argName(a driver.NamedValue) string {
+	if a.Name != "" {
+		return ":" + a.Name
+	}
+
+	return "$" + strconv.Itoa(a.Ordinal)
+}
+
+func placeholderName(r placeholderRef) string {
+	if r.name != "" {
+		return r.name
+	}
+
+	return "p" + strconv.Itoa(r.ordinal)
+}
+
+// findArg returns index of argument of placeholder or -1.
+func findArg(args []driver.NamedValue, r placeholderRef) int {
+	for i, a := range args {
+		if r.name != "" && a.Name == r.name {
+			return i
+		}
+
+		if r.name == "" && a.Ordinal == r.ordinal {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// placeholderRefs finds placeholders in statement.
+//
+// Positional "?" placeholders are numbered in order, "@name" placeholders are not recognized
+// to keep MySQL user variables intact. If statement has "$1" or ":name" placeholders, "?" is
+// an operator of PostgreSQL jsonb, and ":name" in brackets is a bound of array slice.
+func placeholderRefs(statement string) []placeholderRef {
+	var (
+		refs      []placeholderRef
+		questions []placeholderRef
+		question  int
+		brackets  int
+		tokens    = lexSQL(statement)
+		offsets   = sqlOffsets(statement, tokens)
+	)
+
+	for i, t := range tokens {
+		switch {
+		case t.is("["):
+			brackets++
+		case t.is("]") && brackets > 0:
+			brackets--
+		}
+
+		if t.kind != sqlPlaceholder {
+			continue
+		}
+
+		r := placeholderRef{start: offsets[i], end: offsets[i] + len(t.text)}
+
+		switch t.text[0] {
+		case '?':
+			question++
+			r.ordinal = question
+			r.style = PlaceholderQuestion
+
+			questions = append(questions, r)
+
+			continue
+		case '$':
+			if len(t.text) == 1 {
+				continue
+			}
+
+			r.ordinal, _ = strconv.Atoi(t.text[1:])
+			r.style = PlaceholderDollar
+		case ':':
+			if brackets > 0 {
+				continue
+			}
+
+			r.name = t.text[1:]
+			r.style = PlaceholderColon
+		default:
+			continue
+		}
+
+		refs = append(refs, r)
+	}
+
+	if len(refs) == 0 {
+		return questions
+	}
+
+	return refs
+}
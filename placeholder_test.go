@@ -0,0 +1,227 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewritePlaceholders(t *testing.T) {
+	for _, tc := range []struct {
+		style     dbwrap.PlaceholderStyle
+		statement string
+		args      []driver.NamedValue
+		expected  string
+		expArgs   []driver.NamedValue
+		err       string
+	}{
+		{
+			style:     dbwrap.PlaceholderDollar,
+			statement: `SELECT '?', "?", ? /* ? */ FROM t WHERE a = ? AND b = 'it''s ?' -- ?`,
+			args:      []driver.NamedValue{{Ordinal: 1, Value: 1}, {Ordinal: 2, Value: 2}},
+			expected:  `SELECT '?', "?", $1 /* ? */ FROM t WHERE a = $2 AND b = 'it''s ?' -- ?`,
+			expArgs:   []driver.NamedValue{{Ordinal: 1, Value: 1}, {Ordinal: 2, Value: 2}},
+		},
+		{
+			style:     dbwrap.PlaceholderQuestion,
+			statement: "SELECT * FROM t WHERE a = $2 AND b = $1 AND c = $2 AND d::text = $$ $1 $$",
+			args:      []driver.NamedValue{{Ordinal: 1, Value: "x"}, {Ordinal: 2, Value: "y"}},
+			expected:  "SELECT * FROM t WHERE a = ? AND b = ? AND c = ? AND d::text = $$ $1 $$",
+			expArgs: []driver.NamedValue{
+				{Ordinal: 1, Value: "y"}, {Ordinal: 2, Value: "x"}, {Ordinal: 3, Value: "y"},
+			},
+		},
+		{
+			style:     dbwrap.PlaceholderDollar,
+			statement: "UPDATE t SET a = :a, b = :b WHERE a = :a AND c = 'x:y' AND @v = 1",
+			args:      []driver.NamedValue{{Name: "b", Ordinal: 1, Value: 2}, {Name: "a", Ordinal: 2, Value: 1}},
+			expected:  "UPDATE t SET a = $1, b = $2 WHERE a = $1 AND c = 'x:y' AND @v = 1",
+			expArgs:   []driver.NamedValue{{Ordinal: 1, Value: 1}, {Ordinal: 2, Value: 2}},
+		},
+		{
+			style:     dbwrap.PlaceholderColon,
+			statement: "SELECT * FROM t WHERE a = $2 AND b = $1 AND c = $2",
+			args:      []driver.NamedValue{{Ordinal: 1, Value: "x"}, {Ordinal: 2, Value: "y"}},
+			expected:  "SELECT * FROM t WHERE a = :p2 AND b = :p1 AND c = :p2",
+			expArgs:   []driver.NamedValue{{Name: "p2", Ordinal: 1, Value: "y"}, {Name: "p1", Ordinal: 2, Value: "x"}},
+		},
+		{
+			// Backslash is not an escape in standard strings.
+			style:     dbwrap.PlaceholderDollar,
+			statement: `SELECT 'C:\', E'\'?' FROM t WHERE a = ?`,
+			args:      []driver.NamedValue{{Ordinal: 1, Value: 1}},
+			expected:  `SELECT 'C:\', E'\'?' FROM t WHERE a = $1`,
+			expArgs:   []driver.NamedValue{{Ordinal: 1, Value: 1}},
+		},
+		{
+			style:     dbwrap.PlaceholderQuestion,
+			statement: "SELECT * FROM t WHERE a = :a",
+			expected:  "SELECT * FROM t WHERE a = ?",
+		},
+		{
+			// Missing argument.
+			style:     dbwrap.PlaceholderQuestion,
+			statement: "SELECT * FROM t WHERE a = :a",
+			args:      []driver.NamedValue{{Name: "b", Ordinal: 1, Value: 1}},
+			expected:  "SELECT * FROM t WHERE a = :a",
+			expArgs:   []driver.NamedValue{{Name: "b", Ordinal: 1, Value: 1}},
+			err:       "dbwrap: missing argument for placeholder :a",
+		},
+		{
+			// Extra argument of statement that already has style.
+			style:     dbwrap.PlaceholderDollar,
+			statement: "SELECT * FROM t WHERE a = $1",
+			args:      []driver.NamedValue{{Ordinal: 1, Value: 1}, {Ordinal: 2, Value: 2}},
+			expected:  "SELECT * FROM t WHERE a = $1",
+			expArgs:   []driver.NamedValue{{Ordinal: 1, Value: 1}, {Ordinal: 2, Value: 2}},
+			err:       "dbwrap: unused argument $2",
+		},
+		{
+			// Missing argument of statement that already has style.
+			style:     dbwrap.PlaceholderQuestion,
+			statement: "SELECT * FROM t WHERE a = ? AND b = ?",
+			args:      []driver.NamedValue{{Ordinal: 1, Value: 1}},
+			expected:  "SELECT * FROM t WHERE a = ? AND b = ?",
+			expArgs:   []driver.NamedValue{{Ordinal: 1, Value: 1}},
+			err:       "dbwrap: missing argument for placeholder ?",
+		},
+		{
+			// Array slice.
+			style:     dbwrap.PlaceholderDollar,
+			statement: "SELECT a[1:n], b[:n] FROM t WHERE c = :c",
+			args:      []driver.NamedValue{{Name: "c", Ordinal: 1, Value: 1}},
+			expected:  "SELECT a[1:n], b[:n] FROM t WHERE c = $1",
+			expArgs:   []driver.NamedValue{{Ordinal: 1, Value: 1}},
+		},
+		{
+			// PostgreSQL jsonb operator.
+			style:     dbwrap.PlaceholderQuestion,
+			statement: "SELECT * FROM t WHERE d ? 'k' AND e = $1",
+			args:      []driver.NamedValue{{Ordinal: 1, Value: 1}},
+			expected:  "SELECT * FROM t WHERE d ? 'k' AND e = ?",
+			expArgs:   []driver.NamedValue{{Ordinal: 1, Value: 1}},
+		},
+		{
+			style:     dbwrap.PlaceholderColon,
+			statement: "SELECT * FROM t WHERE a = :a",
+			args:      []driver.NamedValue{{Name: "a", Ordinal: 1, Value: 1}},
+			expected:  "SELECT * FROM t WHERE a = :a",
+			expArgs:   []driver.NamedValue{{Name: "a", Ordinal: 1, Value: 1}},
+		},
+	} {
+		t.Run(tc.statement, func(t *testing.T) {
+			statement, args, err := dbwrap.RewritePlaceholders(tc.statement, tc.args, tc.style)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.expected, statement)
+			assert.Equal(t, tc.expArgs, args)
+		})
+	}
+}
+
+func TestWithPlaceholders(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		f           = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		intercepted []string
+	)
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(),
+		dbwrap.WithPlaceholders(dbwrap.PlaceholderDollar),
+		dbwrap.WithInterceptor(func(
+			ctx context.Context,
+			operation dbwrap.Operation,
+			statement string,
+			args []driver.NamedValue,
+		) (context.Context, string, []driver.NamedValue) {
+			intercepted = append(intercepted, statement)
+
+			return ctx, statement, args
+		}),
+	))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err := db.ExecContext(ctx, "UPDATE t SET a = :a WHERE b = :b OR c = :a",
+		sql.Named("b", 2), sql.Named("a", 1))
+	require.NoError(t, err)
+
+	st, err := db.PrepareContext(ctx, "SELECT * FROM t WHERE a = :a AND b = :b AND c = :a")
+	require.NoError(t, err)
+
+	rows, err := st.QueryContext(ctx, sql.Named("b", "y"), sql.Named("a", "x"))
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+	require.NoError(t, st.Close())
+
+	// PostgreSQL jsonb operators are kept.
+	rows, err = db.QueryContext(ctx, "SELECT * FROM t WHERE d ? 'k' AND d ?| array['a'] AND d ?& array['b']")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	rows, err = db.QueryContext(ctx, "SELECT * FROM t WHERE d ?| array['a'] AND e = ?", 1)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	// Arguments that do not match placeholders fail without driver call.
+	_, err = db.ExecContext(ctx, "UPDATE t SET a = :a", sql.Named("b", 1))
+	assert.EqualError(t, err, "dbwrap: missing argument for placeholder :a")
+
+	_, err = db.ExecContext(ctx, "UPDATE t SET a = $1", 1, 2)
+	assert.EqualError(t, err, "dbwrap: unused argument $2")
+
+	st, err = db.PrepareContext(ctx, "SELECT * FROM t WHERE a = :a")
+	require.NoError(t, err)
+
+	_, err = st.ExecContext(ctx, sql.Named("b", 1))
+	assert.EqualError(t, err, "dbwrap: missing argument for placeholder :a")
+	require.NoError(t, st.Close())
+
+	calls := map[string]dbwraptest.Call{}
+
+	for _, c := range f.Calls() {
+		calls[c.Method] = c
+	}
+
+	var queries []string
+
+	for _, c := range f.Calls() {
+		if c.Method == "Conn.QueryContext" {
+			queries = append(queries, c.Statement)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"SELECT * FROM t WHERE d ? 'k' AND d ?| array['a'] AND d ?& array['b']",
+		"SELECT * FROM t WHERE d ?| array['a'] AND e = $1",
+	}, queries)
+
+	c := calls["Conn.ExecContext"]
+	assert.Equal(t, "UPDATE t SET a = $1 WHERE b = $2 OR c = $1", c.Statement)
+	assert.Equal(t, []driver.NamedValue{{Ordinal: 1, Value: int64(1)}, {Ordinal: 2, Value: int64(2)}}, c.Args)
+
+	c = calls["Stmt.QueryContext"]
+	assert.Equal(t, "SELECT * FROM t WHERE a = $1 AND b = $2 AND c = $1", c.Statement)
+	assert.Equal(t, []driver.NamedValue{{Ordinal: 1, Value: "x"}, {Ordinal: 2, Value: "y"}}, c.Args)
+
+	assert.Equal(t, []string{
+		"UPDATE t SET a = $1 WHERE b = $2 OR c = $1",
+		"SELECT * FROM t WHERE a = $1 AND b = $2 AND c = $1",
+		"SELECT * FROM t WHERE a = $1 AND b = $2 AND c = $1",
+		"SELECT * FROM t WHERE d ? 'k' AND d ?| array['a'] AND d ?& array['b']",
+		"SELECT * FROM t WHERE d ?| array['a'] AND e = $1",
+		"SELECT * FROM t WHERE a = $1",
+	}, intercepted)
+}
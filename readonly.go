@@ -0,0 +1,263 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// ReadOnlyError describes a statement rejected in read-only mode.
+type ReadOnlyError struct {
+	Operation Operation
+	Statement string
+}
+
+// Error implements error.
+func (e *ReadOnlyError) Error() string {
+	return "dbwrap: write statement is rejected in read-only mode: " + e.Statement
+}
+
+type readOnlyCtxKey struct{}
+
+// ReadOnlyContext marks context to only allow read operations if db wrapper has WithReadOnly option.
+func ReadOnlyContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyCtxKey{}, true)
+}
+
+// WithReadOnly rejects statements that are not classified as reads with *ReadOnlyError
+// and forces driver.TxOptions.ReadOnly for transactions.
+//
+// If always is false, only operations with context from ReadOnlyContext and operations of
+// read-only transactions are restricted.
+//
+// Statement classifier recognizes common table expressions, locking reads (SELECT ... FOR UPDATE),
+// SELECT ... INTO and stacked statements, but it does not know side effects of functions.
+func WithReadOnly(always bool) Option {
+	mode := readOnlyContext
+	if always {
+		mode = readOnlyAlways
+	}
+
+	return func(o *Options) {
+		o.readOnly = mode
+
+		withHook(hook{
+			query: mode.query,
+			exec:  mode.exec,
+			call:  mode.call,
+		})(o)
+	}
+}
+
+type readOnlyMode int
+
+const (
+	readOnlyOff = readOnlyMode(iota)
+	readOnlyContext
+	readOnlyAlways
+)
+
+// isReadOnly checks if operation is restricted to reads.
+func (o Options) isReadOnly(ctx context.Context) bool {
+	return o.readOnly.restricts(ctx)
+}
+
+// restricts checks if operation is restricted to reads.
+func (m readOnlyMode) restricts(ctx context.Context) bool {
+	switch m {
+	case readOnlyOff:
+		return false
+	case readOnlyAlways:
+		return true
+	}
+
+	if tx := txFromContext(ctx); tx != nil && tx.readOnly {
+		return true
+	}
+
+	ro, _ := ctx.Value(readOnlyCtxKey{}).(bool)
+
+	return ro
+}
+
+// check returns error for write statement in read-only mode.
+func (m readOnlyMode) check(ctx context.Context, operation Operation, statement string) error {
+	if m.restricts(ctx) && !parseStatement(ctx, statement).statement().ReadOnly {
+		return &ReadOnlyError{Operation: operation, Statement: statement}
+	}
+
+	return nil
+}
+
+func (m readOnlyMode) query(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+	next func(ctx context.Context) (driver.Rows, error),
+) (driver.Rows, error) {
+	if err := m.check(ctx, operation, statement); err != nil {
+		return nil, err
+	}
+
+	return next(ctx)
+}
+
+func (m readOnlyMode) exec(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+	next func(ctx context.Context) (driver.Result, error),
+) (driver.Result, error) {
+	if err := m.check(ctx, operation, statement); err != nil {
+		return nil, err
+	}
+
+	return next(ctx)
+}
+
+func (m readOnlyMode) call(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	next func(ctx context.Context) error,
+) error {
+	if operation == Prepare {
+		if err := m.check(ctx, operation, statement); err != nil {
+			return err
+		}
+	}
+
+	return next(ctx)
+}
+
+// isReadStatement checks if statement only reads data.
+func isReadStatement(tokens []sqlToken) bool {
+	tokens = significant(tokens)
+
+	// Stacked statements are classified separately.
+	for i, t := range tokens {
+		if t.is(";") {
+			return isReadStatement(tokens[:i]) && (len(tokens[i+1:]) == 0 || isReadStatement(tokens[i+1:]))
+		}
+	}
+
+	verb := sqlVerb(tokens)
+
+	switch verb {
+	case "EXPLAIN":
+		// EXPLAIN ANALYZE executes statement.
+		for i, t := range tokens {
+			if t.keyword() == "ANALYZE" {
+				return isReadStatement(tokens[i+1:])
+			}
+		}
+
+		return true
+	case "SHOW", "DESCRIBE", "DESC":
+		return true
+	case "SELECT", "VALUES", "TABLE":
+	default:
+		return false
+	}
+
+	depth := 0
+
+	for i, t := range tokens {
+		switch {
+		case t.is("("):
+			depth++
+
+			// Data-modifying statement in common table expression or subquery.
+			if i+1 < len(tokens) {
+				switch tokens[i+1].keyword() {
+				case "INSERT", "UPDATE", "DELETE", "MERGE":
+					return false
+				}
+			}
+		case t.is(")"):
+			depth--
+		case t.keyword() == "INTO" && depth == 0:
+			// SELECT ... INTO creates table or writes file.
+			return false
+		case isLockingClause(tokens[i:]):
+			return false
+		}
+	}
+
+	return true
+}
+
+// lockingClauses are keywords of clauses that make locking read.
+var lockingClauses = [][]string{
+	{"FOR", "UPDATE"},
+	{"FOR", "NO", "KEY", "UPDATE"},
+	{"FOR", "SHARE"},
+	{"FOR", "KEY", "SHARE"},
+	{"LOCK", "IN", "SHARE", "MODE"}, // MySQL.
+}
+
+// isLockingClause checks if tokens start with locking clause that ends SELECT or subquery.
+//
+// Locking clause can only be followed by lock options, other locking clauses,
+// or end of subquery, so that columns named lock are not mistaken for locking.
+func isLockingClause(tokens []sqlToken) bool {
+	n := 0
+
+	for _, keywords := range lockingClauses {
+		if hasKeywords(tokens, keywords) {
+			n = len(keywords)
+
+			break
+		}
+	}
+
+	if n == 0 {
+		return false
+	}
+
+	for i := n; i < len(tokens); i++ {
+		t := tokens[i]
+
+		switch {
+		case t.is(")"):
+			return true
+		case t.keyword() == "OF":
+			// Locked tables.
+			for i+1 < len(tokens) && (tokens[i+1].kind == sqlIdent || tokens[i+1].is(",") || tokens[i+1].is(".") ||
+				(tokens[i+1].kind == sqlWord && !isLockOption(tokens[i+1].keyword()))) {
+				i++
+			}
+		case isLockOption(t.keyword()), t.kind == sqlNumber:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isLockOption checks if keyword can follow locking clause.
+func isLockOption(keyword string) bool {
+	switch keyword {
+	case "NOWAIT", "SKIP", "LOCKED", "WAIT", "FOR", "UPDATE", "SHARE", "NO", "KEY":
+		return true
+	}
+
+	return false
+}
+
+// hasKeywords checks if tokens start with keywords.
+func hasKeywords(tokens []sqlToken, keywords []string) bool {
+	if len(tokens) < len(keywords) {
+		return false
+	}
+
+	for i, k := range keywords {
+		if tokens[i].keyword() != k {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,75 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithReadOnly(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		f      = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		txOpts []driver.TxOptions
+	)
+
+	f.Begin = func(ctx context.Context, opts driver.TxOptions) error {
+		txOpts = append(txOpts, opts)
+
+		return nil
+	}
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithReadOnly(false)))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err := db.ExecContext(ctx, "DELETE FROM t")
+	require.NoError(t, err)
+
+	roCtx := dbwrap.ReadOnlyContext(ctx)
+
+	_, err = db.ExecContext(roCtx, "DELETE FROM t")
+
+	re, ok := err.(*dbwrap.ReadOnlyError)
+	require.True(t, ok)
+	assert.Equal(t, dbwrap.Exec, re.Operation)
+	assert.EqualError(t, err, "dbwrap: write statement is rejected in read-only mode: DELETE FROM t")
+
+	_, err = db.PrepareContext(roCtx, "SELECT a FROM t FOR UPDATE")
+	assert.Error(t, err)
+
+	rows, err := db.QueryContext(roCtx, "WITH a AS (SELECT 1) SELECT * FROM a")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	// Read-only transaction restricts operations with any context.
+	tx, err := db.BeginTx(roCtx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(ctx, "UPDATE t SET a = 1")
+	assert.Error(t, err)
+	require.NoError(t, tx.Rollback())
+
+	tx, err = db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(ctx, "UPDATE t SET a = 1")
+	assert.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	assert.Equal(t, []driver.TxOptions{{ReadOnly: true}, {}}, txOpts)
+
+	db2 := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithReadOnly(true)))
+
+	_, err = db2.ExecContext(ctx, "INSERT INTO t VALUES (1)")
+	assert.Error(t, err)
+	require.NoError(t, db2.Close())
+}
@@ -0,0 +1,228 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Recorder writes database operations with their responses to a cassette in JSON Lines format.
+//
+// Recorded cassette can be served by Replayer to run tests without a database.
+// Only the first result set of rows is recorded.
+type Recorder struct {
+	seq int64
+
+	mu     sync.Mutex
+	w      io.Writer
+	header bool
+	err    error
+}
+
+// NewRecorder creates Recorder that writes cassette to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// WithRecorder adds recorder to a db wrapper.
+//
+// Recorder receives statements and arguments after interceptor.
+func WithRecorder(r *Recorder) Option {
+	return withHook(hook{
+		query: r.query,
+		exec:  r.exec,
+		call:  r.call,
+	})
+}
+
+// Err returns the first error of writing cassette.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.err
+}
+
+func (r *Recorder) interaction(operation Operation, statement string, args []driver.NamedValue) Interaction {
+	return Interaction{
+		Seq:       int(atomic.AddInt64(&r.seq, 1)),
+		Operation: operation,
+		Statement: statement,
+		Args:      cassetteValues(args),
+	}
+}
+
+func (r *Recorder) write(i Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+
+	if !r.header {
+		r.header = true
+
+		if r.err = r.writeLine(cassetteHeader{Version: CassetteVersion}); r.err != nil {
+			return
+		}
+	}
+
+	r.err = r.writeLine(i)
+}
+
+func (r *Recorder) writeLine(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.w.Write(append(b, '\n'))
+
+	return err
+}
+
+func (r *Recorder) call(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	next func(ctx context.Context) error,
+) error {
+	i := r.interaction(operation, statement, nil)
+	err := next(ctx)
+	i.Error = cassetteError(err)
+
+	r.write(i)
+
+	return err
+}
+
+func (r *Recorder) exec(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+	next func(ctx context.Context) (driver.Result, error),
+) (driver.Result, error) {
+	i := r.interaction(operation, statement, args)
+
+	res, err := next(ctx)
+	if err != nil {
+		i.Error = cassetteError(err)
+	} else {
+		var cr CassetteResult
+
+		id, idErr := res.LastInsertId()
+		cr.LastInsertID, cr.LastInsertIDError = id, cassetteError(idErr)
+
+		cnt, cntErr := res.RowsAffected()
+		cr.RowsAffected, cr.RowsAffectedError = cnt, cassetteError(cntErr)
+
+		i.Result = &cr
+	}
+
+	r.write(i)
+
+	return res, err
+}
+
+func (r *Recorder) query(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+	next func(ctx context.Context) (driver.Rows, error),
+) (driver.Rows, error) {
+	i := r.interaction(operation, statement, args)
+
+	rows, err := next(ctx)
+	if err != nil {
+		i.Error = cassetteError(err)
+		r.write(i)
+
+		return nil, err
+	}
+
+	i.Columns = cassetteColumns(rows)
+	done, closed := false, false
+
+	return decorateRows(rows,
+		func(dest []driver.Value) error {
+			err := rows.Next(dest)
+
+			switch {
+			case done:
+			case err == nil:
+				row := make([]driver.NamedValue, len(dest))
+				for j, v := range dest {
+					row[j].Value = v
+				}
+
+				i.Rows = append(i.Rows, cassetteValues(row))
+			case err != io.EOF:
+				i.RowsError = cassetteError(err)
+				done = true
+			default:
+				done = true
+			}
+
+			return err
+		},
+		func() error {
+			err := rows.Close()
+
+			if !closed {
+				closed = true
+
+				r.write(i)
+			}
+
+			return err
+		},
+	), nil
+}
+
+// cassetteColumns collects column metadata of rows.
+func cassetteColumns(rows driver.Rows) []CassetteColumn {
+	names := rows.Columns()
+	columns := make([]CassetteColumn, len(names))
+
+	for j, name := range names {
+		c := CassetteColumn{Name: name}
+
+		if r, ok := rows.(driver.RowsColumnTypeScanType); ok {
+			c.scanType = r.ColumnTypeScanType(j)
+			c.ScanType = c.scanType.String()
+		}
+
+		if r, ok := rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+			c.DatabaseTypeName = r.ColumnTypeDatabaseTypeName(j)
+		}
+
+		if r, ok := rows.(driver.RowsColumnTypeLength); ok {
+			if length, ok := r.ColumnTypeLength(j); ok {
+				c.Length = &length
+			}
+		}
+
+		if r, ok := rows.(driver.RowsColumnTypeNullable); ok {
+			if nullable, ok := r.ColumnTypeNullable(j); ok {
+				c.Nullable = &nullable
+			}
+		}
+
+		if r, ok := rows.(driver.RowsColumnTypePrecisionScale); ok {
+			if precision, scale, ok := r.ColumnTypePrecisionScale(j); ok {
+				c.Precision = &precision
+				c.Scale = &scale
+			}
+		}
+
+		columns[j] = c
+	}
+
+	return columns
+}
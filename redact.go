@@ -0,0 +1,251 @@
+package dbwrap
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultRedactNames are substrings of sensitive parameter and column names.
+var DefaultRedactNames = []string{
+	"password", "passwd", "secret", "token", "apikey", "email", "ssn", "cardnumber", "cvv",
+}
+
+// DefaultRedactPatterns match emails and payment card numbers.
+var DefaultRedactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[A-Za-z]{2,}$`),
+	regexp.MustCompile(`^\d{4}[ -]?\d{4}[ -]?\d{4}[ -]?\d{1,7}$`),
+}
+
+// RedactConfig controls Redactor.
+type RedactConfig struct {
+	// Names are case-insensitive substrings of sensitive parameter and column names,
+	// underscores and dashes are ignored, default DefaultRedactNames.
+	Names []string
+
+	// Patterns match sensitive string and []byte values, default DefaultRedactPatterns.
+	Patterns []*regexp.Regexp
+
+	// Mask replaces sensitive values, default "[REDACTED]".
+	Mask string
+}
+
+// Redactor hides sensitive arguments of statements for logging and tracing.
+//
+// Argument is sensitive if it has a sensitive name (driver.NamedValue.Name), if its placeholder
+// is compared with or inserted into a sensitive column (password = ?), or if its value matches a pattern.
+type Redactor struct {
+	names    []string
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+// NewRedactor creates Redactor.
+func NewRedactor(config RedactConfig) *Redactor {
+	r := Redactor{
+		patterns: config.Patterns,
+		mask:     config.Mask,
+	}
+
+	if config.Names == nil {
+		config.Names = DefaultRedactNames
+	}
+
+	if r.patterns == nil {
+		r.patterns = DefaultRedactPatterns
+	}
+
+	if r.mask == "" {
+		r.mask = "[REDACTED]"
+	}
+
+	for _, n := range config.Names {
+		r.names = append(r.names, normalizeRedactName(n))
+	}
+
+	return &r
+}
+
+// Redact returns a copy of args with sensitive values replaced by mask, args are not modified.
+func (r *Redactor) Redact(statement string, args []driver.NamedValue) []driver.NamedValue {
+	if len(args) == 0 {
+		return nil
+	}
+
+	columns, named := placeholderColumns(lexSQL(statement))
+	res := make([]driver.NamedValue, len(args))
+
+	for i, a := range args {
+		column := named[a.Name]
+		if a.Name == "" {
+			column = columns[a.Ordinal]
+		}
+
+		switch {
+		case r.sensitiveName(a.Name) || r.sensitiveName(column) || r.sensitiveValue(a.Value):
+			a.Value = r.mask
+		default:
+			// Buffers may be reused by the caller.
+			if b, ok := a.Value.([]byte); ok {
+				a.Value = append([]byte(nil), b...)
+			}
+		}
+
+		res[i] = a
+	}
+
+	return res
+}
+
+func (r *Redactor) sensitiveName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	name = normalizeRedactName(name)
+
+	for _, n := range r.names {
+		if strings.Contains(name, n) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Redactor) sensitiveValue(v driver.Value) bool {
+	var s string
+
+	switch x := v.(type) {
+	case string:
+		s = x
+	case []byte:
+		s = string(x)
+	default:
+		return false
+	}
+
+	for _, p := range r.patterns {
+		if p.MatchString(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeRedactName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "", `"`, "", "`", "").Replace(name))
+}
+
+// placeholderColumns maps placeholders to columns they are compared with or inserted into.
+//
+// Positional placeholders are mapped by 1-based ordinal, named placeholders (:name, @name) by name.
+func placeholderColumns(tokens []sqlToken) (columns map[int]string, named map[string]string) {
+	var (
+		ordinal    int
+		depth      int
+		insert     = sqlVerb(tokens) == "INSERT"
+		insertCols []string
+		inColList  bool
+		inValues   bool
+		valuePos   int
+		listCol    string
+		listDepth  int
+	)
+
+	columns = map[int]string{}
+	named = map[string]string{}
+	tokens = significant(tokens)
+
+	for i, t := range tokens {
+		switch {
+		case t.is("("):
+			depth++
+
+			switch {
+			case insert && depth == 1 && !inValues && insertCols == nil && i > 0 && isColumnToken(tokens[i-1]):
+				inColList = true
+			case inValues && depth == 1:
+				valuePos = 0
+			case i >= 2 && tokens[i-1].keyword() == "IN" && isColumnToken(tokens[i-2]):
+				listCol, listDepth = columnName(tokens[i-2]), depth
+			}
+		case t.is(")"):
+			if depth == listDepth {
+				listCol, listDepth = "", 0
+			}
+
+			depth--
+			inColList = false
+		case t.is(","):
+			if inValues && depth == 1 {
+				valuePos++
+			}
+		case inColList && isColumnToken(t):
+			insertCols = append(insertCols, columnName(t))
+		case depth == 0 && t.keyword() == "VALUES":
+			inValues = insert
+		case depth == 0 && (t.keyword() == "SELECT" || t.keyword() == "ON" || t.keyword() == "RETURNING"):
+			inValues = false
+		case t.kind == sqlPlaceholder:
+			ordinal++
+
+			name := ""
+			key := ordinal
+
+			switch t.text[0] {
+			case '$':
+				key, _ = strconv.Atoi(t.text[1:])
+			case ':', '@':
+				name = t.text[1:]
+			}
+
+			column := ""
+
+			switch {
+			case inValues && depth == 1 && valuePos < len(insertCols):
+				column = insertCols[valuePos]
+			case listCol != "" && depth == listDepth:
+				column = listCol
+			case i >= 2 && isComparison(tokens[i-1]) && isColumnToken(tokens[i-2]):
+				column = columnName(tokens[i-2])
+			}
+
+			if column == "" {
+				continue
+			}
+
+			if name != "" {
+				named[name] = column
+			} else {
+				columns[key] = column
+			}
+		}
+	}
+
+	return columns, named
+}
+
+func isColumnToken(t sqlToken) bool {
+	return t.kind == sqlIdent || (t.kind == sqlWord && !sqlClauses[t.keyword()])
+}
+
+func columnName(t sqlToken) string {
+	return strings.ToLower(strings.Trim(t.text, "\"`"))
+}
+
+func isComparison(t sqlToken) bool {
+	switch t.text {
+	case "=", "==", "<>", "!=", "<", ">", "<=", ">=":
+		return t.kind == sqlPunct
+	}
+
+	switch t.keyword() {
+	case "LIKE", "ILIKE":
+		return true
+	}
+
+	return false
+}
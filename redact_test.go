@@ -0,0 +1,117 @@
+package dbwrap_test
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	r := dbwrap.NewRedactor(dbwrap.RedactConfig{})
+
+	for _, tc := range []struct {
+		statement string
+		args      []driver.NamedValue
+		redacted  []int
+	}{
+		{
+			statement: "UPDATE users SET password = ? WHERE id = ?",
+			args:      []driver.NamedValue{{Ordinal: 1, Value: "secret"}, {Ordinal: 2, Value: int64(1)}},
+			redacted:  []int{0},
+		},
+		{
+			statement: `SELECT * FROM users WHERE u."Email" LIKE $2 AND id = $1`,
+			args:      []driver.NamedValue{{Ordinal: 1, Value: int64(1)}, {Ordinal: 2, Value: "%bob%"}},
+			redacted:  []int{1},
+		},
+		{
+			statement: "INSERT INTO users (name, api_key, age) VALUES (?, ?, ?), ('x', ?, ?)",
+			args: []driver.NamedValue{
+				{Ordinal: 1, Value: "bob"}, {Ordinal: 2, Value: "k1"}, {Ordinal: 3, Value: int64(30)},
+				{Ordinal: 4, Value: "k2"}, {Ordinal: 5, Value: int64(40)},
+			},
+			redacted: []int{1, 3},
+		},
+		{
+			statement: "SELECT * FROM sessions WHERE token IN (?, ?) AND user_id = ?",
+			args:      []driver.NamedValue{{Ordinal: 1, Value: "a"}, {Ordinal: 2, Value: "b"}, {Ordinal: 3, Value: "c"}},
+			redacted:  []int{0, 1},
+		},
+		{
+			statement: "UPDATE users SET pass_hash = :h, name = :name WHERE id = :id -- password = :name",
+			args: []driver.NamedValue{
+				{Name: "h", Ordinal: 1, Value: "x"}, {Name: "name", Ordinal: 2, Value: "bob"},
+				{Name: "id", Ordinal: 3, Value: "1"},
+			},
+		},
+		{
+			statement: "UPDATE users SET name = :name WHERE id = :id",
+			args:      []driver.NamedValue{{Name: "name", Ordinal: 1, Value: "bob"}, {Name: "user_password", Ordinal: 2, Value: "1"}},
+			redacted:  []int{1},
+		},
+		{
+			statement: "SELECT 'password = ?', ?, ? FROM t",
+			args: []driver.NamedValue{
+				{Ordinal: 1, Value: "bob@example.com"}, {Ordinal: 2, Value: []byte("4111 1111 1111 1111")},
+				{Ordinal: 3, Value: "plain"},
+			},
+			redacted: []int{0, 1},
+		},
+	} {
+		t.Run(tc.statement, func(t *testing.T) {
+			orig := append([]driver.NamedValue(nil), tc.args...)
+			res := r.Redact(tc.statement, tc.args)
+
+			assert.Equal(t, orig, tc.args, "args must not be modified")
+			assert.Len(t, res, len(tc.args))
+
+			redacted := 0
+
+			for i, a := range res {
+				assert.Equal(t, tc.args[i].Name, a.Name)
+				assert.Equal(t, tc.args[i].Ordinal, a.Ordinal)
+
+				if a.Value == "[REDACTED]" {
+					if assert.Less(t, redacted, len(tc.redacted)) {
+						assert.Equal(t, tc.redacted[redacted], i)
+					}
+
+					redacted++
+				} else {
+					assert.Equal(t, tc.args[i].Value, a.Value)
+				}
+			}
+
+			assert.Equal(t, len(tc.redacted), redacted)
+		})
+	}
+}
+
+func TestRedactor_Redact_config(t *testing.T) {
+	r := dbwrap.NewRedactor(dbwrap.RedactConfig{
+		Names:    []string{"PIN"},
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`^sk_`)},
+		Mask:     "*",
+	})
+
+	buf := []byte("abc")
+	args := []driver.NamedValue{
+		{Ordinal: 1, Value: "1234"}, {Ordinal: 2, Value: "sk_live"}, {Ordinal: 3, Value: "secret"},
+		{Ordinal: 4, Value: buf},
+	}
+
+	res := r.Redact("UPDATE cards SET pin = ?, key = ?, password = ?, data = ?", args)
+
+	assert.Equal(t, []driver.NamedValue{
+		{Ordinal: 1, Value: "*"}, {Ordinal: 2, Value: "*"}, {Ordinal: 3, Value: "secret"},
+		{Ordinal: 4, Value: []byte("abc")},
+	}, res)
+
+	buf[0] = 'x'
+	assert.Equal(t, []byte("abc"), res[3].Value, "buffers must be copied")
+
+	assert.Nil(t, r.Redact("SELECT 1", nil))
+}
@@ -0,0 +1,409 @@
+//go:build go1.10
+// +build go1.10
+
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Compile time assertions.
+var (
+	_ driver.Driver                         = &Replayer{}
+	_ driver.Connector                      = &Replayer{}
+	_ driver.ExecerContext                  = &replayConn{}
+	_ driver.QueryerContext                 = &replayConn{}
+	_ driver.ConnPrepareContext             = &replayConn{}
+	_ driver.ConnBeginTx                    = &replayConn{}
+	_ driver.Pinger                         = &replayConn{}
+	_ driver.StmtExecContext                = &replayStmt{}
+	_ driver.StmtQueryContext               = &replayStmt{}
+	_ driver.RowsColumnTypeScanType         = &replayRows{}
+	_ driver.RowsColumnTypeDatabaseTypeName = &replayRows{}
+	_ driver.RowsColumnTypeLength           = &replayRows{}
+	_ driver.RowsColumnTypeNullable         = &replayRows{}
+	_ driver.RowsColumnTypePrecisionScale   = &replayRows{}
+)
+
+// ReplayMode controls matching of operations with cassette interactions.
+type ReplayMode int
+
+const (
+	// ReplayStrict requires operations to come in recorded order
+	// with the same statements and arguments.
+	ReplayStrict ReplayMode = iota
+
+	// ReplayLoose matches operations with interactions regardless of order,
+	// statements are compared with normalized whitespace, interactions can be reused.
+	// Ping, Begin, Commit and Rollback that are missing in cassette succeed.
+	ReplayLoose
+)
+
+// ReplayMismatchError describes an operation that is not expected by cassette.
+type ReplayMismatchError struct {
+	Operation Operation
+	Statement string
+	Args      []CassetteValue
+
+	// Expected is the closest interaction, nil if there is none.
+	Expected *Interaction
+}
+
+// Error describes difference between expected and actual operation.
+func (e *ReplayMismatchError) Error() string {
+	expected := "end of cassette"
+	if e.Expected != nil {
+		expected = describeOperation(e.Expected.Operation, e.Expected.Statement, e.Expected.Args)
+	}
+
+	return "dbwrap: unexpected " + string(e.Operation) + " in cassette replay\n" +
+		"- expected: " + expected + "\n" +
+		"+ actual:   " + describeOperation(e.Operation, e.Statement, e.Args)
+}
+
+func describeOperation(operation Operation, statement string, args []CassetteValue) string {
+	res := string(operation)
+
+	if statement != "" {
+		res += " " + statement
+	}
+
+	if len(args) > 0 {
+		s := make([]string, 0, len(args))
+
+		for _, a := range args {
+			if a.Name != "" {
+				s = append(s, a.Name+"="+a.String())
+			} else {
+				s = append(s, a.String())
+			}
+		}
+
+		res += " [" + strings.Join(s, ", ") + "]"
+	}
+
+	return res
+}
+
+// Replayer is a driver.Driver and driver.Connector that serves responses from a cassette.
+//
+// If cassette was recorded with an interceptor, the same interceptor should be used with Replayer.
+type Replayer struct {
+	mode ReplayMode
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+	pos          int
+}
+
+// NewReplayer creates Replayer for a cassette.
+func NewReplayer(c *Cassette, mode ReplayMode) *Replayer {
+	return &Replayer{
+		mode:         mode,
+		interactions: c.Interactions,
+		used:         make([]bool, len(c.Interactions)),
+	}
+}
+
+// Open implements driver.Driver.
+func (r *Replayer) Open(_ string) (driver.Conn, error) {
+	return &replayConn{r: r}, nil
+}
+
+// Connect implements driver.Connector.
+func (r *Replayer) Connect(_ context.Context) (driver.Conn, error) {
+	return &replayConn{r: r}, nil
+}
+
+// Driver implements driver.Connector.
+func (r *Replayer) Driver() driver.Driver {
+	return r
+}
+
+// Unused returns interactions that were not replayed.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var res []Interaction
+
+	for i, used := range r.used {
+		if !used {
+			res = append(res, r.interactions[i])
+		}
+	}
+
+	return res
+}
+
+func isControl(operation Operation) bool {
+	switch operation {
+	case Ping, Begin, Commit, Rollback:
+		return true
+	default:
+		return false
+	}
+}
+
+func normalizeStatement(statement string) string {
+	return strings.Join(strings.Fields(statement), " ")
+}
+
+func equalArgs(a, b []CassetteValue) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Name != b[i].Name ||
+			fmt.Sprintf("%T", a[i].Value) != fmt.Sprintf("%T", b[i].Value) ||
+			a[i].String() != b[i].String() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// match finds interaction for an operation.
+//
+// It returns driver.ErrSkip for Exec and Query that were recorded with a fallback to prepared statement.
+func (r *Replayer) match(operation Operation, statement string, args []driver.NamedValue) (*Interaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cargs := cassetteValues(args)
+
+	if r.mode == ReplayStrict {
+		return r.matchStrict(operation, statement, cargs)
+	}
+
+	return r.matchLoose(operation, statement, cargs)
+}
+
+func (r *Replayer) matchStrict(operation Operation, statement string, args []CassetteValue) (*Interaction, error) {
+	if r.pos >= len(r.interactions) {
+		return nil, &ReplayMismatchError{Operation: operation, Statement: statement, Args: args}
+	}
+
+	i := &r.interactions[r.pos]
+
+	if (operation == Exec || operation == Query) && i.Operation == Prepare && i.Statement == statement {
+		return nil, driver.ErrSkip
+	}
+
+	if i.Operation != operation || i.Statement != statement || !equalArgs(i.Args, args) {
+		return nil, &ReplayMismatchError{Operation: operation, Statement: statement, Args: args, Expected: i}
+	}
+
+	r.used[r.pos] = true
+	r.pos++
+
+	return i, nil
+}
+
+func (r *Replayer) matchLoose(operation Operation, statement string, args []CassetteValue) (*Interaction, error) {
+	var (
+		normalized = normalizeStatement(statement)
+		reused     = -1
+		closest    = -1
+		prepared   = false
+	)
+
+	for j := range r.interactions {
+		i := &r.interactions[j]
+
+		if i.Operation == Prepare && (operation == Exec || operation == Query) &&
+			normalizeStatement(i.Statement) == normalized {
+			prepared = true
+		}
+
+		if i.Operation != operation {
+			continue
+		}
+
+		if normalizeStatement(i.Statement) != normalized {
+			if closest == -1 {
+				closest = j
+			}
+
+			continue
+		}
+
+		if !equalArgs(i.Args, args) {
+			closest = j
+
+			continue
+		}
+
+		if !r.used[j] {
+			r.used[j] = true
+
+			return i, nil
+		}
+
+		reused = j
+	}
+
+	switch {
+	case reused != -1:
+		return &r.interactions[reused], nil
+	case prepared:
+		return nil, driver.ErrSkip
+	case isControl(operation):
+		return &Interaction{Operation: operation}, nil
+	}
+
+	e := &ReplayMismatchError{Operation: operation, Statement: statement, Args: args}
+	if closest != -1 {
+		e.Expected = &r.interactions[closest]
+	}
+
+	return nil, e
+}
+
+// replayConn implements driver.Conn.
+type replayConn struct {
+	r *Replayer
+}
+
+func (c *replayConn) call(operation Operation, statement string) error {
+	i, err := c.r.match(operation, statement, nil)
+	if err != nil {
+		return err
+	}
+
+	return replayedError(i.Error)
+}
+
+func (c *replayConn) exec(operation Operation, statement string, args []driver.NamedValue) (driver.Result, error) {
+	i, err := c.r.match(operation, statement, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := replayedError(i.Error); err != nil {
+		return nil, err
+	}
+
+	res := replayResult{}
+	if i.Result != nil {
+		res.r = *i.Result
+	}
+
+	return res, nil
+}
+
+func (c *replayConn) query(operation Operation, statement string, args []driver.NamedValue) (driver.Rows, error) {
+	i, err := c.r.match(operation, statement, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := replayedError(i.Error); err != nil {
+		return nil, err
+	}
+
+	return &replayRows{i: i}, nil
+}
+
+func (c *replayConn) Ping(_ context.Context) error {
+	return c.call(Ping, "")
+}
+
+func (c *replayConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.exec(Exec, query, args)
+}
+
+func (c *replayConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.query(Query, query, args)
+}
+
+func (c *replayConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *replayConn) PrepareContext(_ context.Context, query string) (driver.Stmt, error) {
+	if err := c.call(Prepare, query); err != nil {
+		return nil, err
+	}
+
+	return &replayStmt{c: c, query: query}, nil
+}
+
+func (c *replayConn) Close() error {
+	return nil
+}
+
+func (c *replayConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *replayConn) BeginTx(_ context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	if err := c.call(Begin, ""); err != nil {
+		return nil, err
+	}
+
+	return replayTx{c: c}, nil
+}
+
+// replayTx implements driver.Tx.
+type replayTx struct {
+	c *replayConn
+}
+
+func (t replayTx) Commit() error {
+	return t.c.call(Commit, "")
+}
+
+func (t replayTx) Rollback() error {
+	return t.c.call(Rollback, "")
+}
+
+// replayStmt implements driver.Stmt.
+type replayStmt struct {
+	c     *replayConn
+	query string
+}
+
+func (s *replayStmt) Close() error {
+	return nil
+}
+
+func (s *replayStmt) NumInput() int {
+	return -1
+}
+
+func (s *replayStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.c.exec(StmtExec, s.query, namedValues(args))
+}
+
+func (s *replayStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.c.query(StmtQuery, s.query, namedValues(args))
+}
+
+func (s *replayStmt) ExecContext(_ context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.c.exec(StmtExec, s.query, args)
+}
+
+func (s *replayStmt) QueryContext(_ context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.c.query(StmtQuery, s.query, args)
+}
+
+// replayResult implements driver.Result.
+type replayResult struct {
+	r CassetteResult
+}
+
+func (r replayResult) LastInsertId() (int64, error) {
+	return r.r.LastInsertID, replayedError(r.r.LastInsertIDError)
+}
+
+func (r replayResult) RowsAffected() (int64, error) {
+	return r.r.RowsAffected, replayedError(r.r.RowsAffectedError)
+}
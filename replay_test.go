@@ -0,0 +1,209 @@
+package dbwrap_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bool64/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runReplayScenario(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	ctx := context.Background()
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	rows, err := db.QueryContext(ctx, "SELECT id, name, created FROM users WHERE id > ?", 10)
+	require.NoError(t, err)
+
+	types, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, "INT", types[0].DatabaseTypeName())
+	assert.Equal(t, reflect.TypeOf(int64(0)), types[0].ScanType())
+
+	var (
+		ids     []int64
+		names   []string
+		created []time.Time
+	)
+
+	for rows.Next() {
+		var (
+			id   int64
+			name string
+			c    time.Time
+		)
+
+		require.NoError(t, rows.Scan(&id, &name, &c))
+
+		ids = append(ids, id)
+		names = append(names, name)
+		created = append(created, c)
+	}
+
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+
+	assert.Equal(t, []int64{11, 12}, ids)
+	assert.Equal(t, []string{"foo", "bar"}, names)
+	assert.Equal(t, []time.Time{ts, ts}, created)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	res, err := tx.ExecContext(ctx, "UPDATE users SET name = ? WHERE id = ?", "baz", 11)
+	require.NoError(t, err)
+
+	aff, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), aff)
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM users")
+	assert.EqualError(t, err, "permission denied")
+
+	require.NoError(t, tx.Commit())
+}
+
+func TestRecorder(t *testing.T) {
+	_, mock, err := sqlmock.NewWithDSN("recorder")
+	require.NoError(t, err)
+
+	var (
+		cassette = bytes.NewBuffer(nil)
+		rec      = dbwrap.NewRecorder(cassette)
+		ts       = time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	)
+
+	driverName, err := dbwrap.Register("sqlmock", dbwrap.WithRecorder(rec))
+	require.NoError(t, err)
+
+	db, err := sql.Open(driverName, "recorder")
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT id, name, created FROM users WHERE id > ?").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("id").OfType("INT", int64(0)),
+			sqlmock.NewColumn("name").OfType("VARCHAR", "").Nullable(true),
+			sqlmock.NewColumn("created").OfType("DATETIME", ts),
+		).AddRow(11, "foo", ts).AddRow(12, "bar", ts))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users").WithArgs("baz", 11).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM users").WillReturnError(errors.New("permission denied"))
+	mock.ExpectCommit()
+	mock.ExpectClose()
+
+	runReplayScenario(t, db)
+	require.NoError(t, db.Close())
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.NoError(t, rec.Err())
+
+	lines := strings.Split(strings.TrimSpace(cassette.String()), "\n")
+	require.Len(t, lines, 6)
+	assert.Equal(t, `{"version":1}`, lines[0])
+	assert.Equal(t, `{"seq":3,"operation":"exec","statement":"UPDATE users SET name = ? WHERE id = ?",`+
+		`"args":[{"ordinal":1,"type":"string","value":"baz"},{"ordinal":2,"type":"int64","value":"11"}],`+
+		`"result":{"lastInsertId":0,"rowsAffected":1}}`, lines[3])
+
+	c, err := dbwrap.LoadCassette(bytes.NewReader(cassette.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, c.Interactions, 5)
+
+	for _, mode := range []dbwrap.ReplayMode{dbwrap.ReplayStrict, dbwrap.ReplayLoose} {
+		r := dbwrap.NewReplayer(c, mode)
+		db := sql.OpenDB(r)
+
+		runReplayScenario(t, db)
+		require.NoError(t, db.Close())
+		assert.Empty(t, r.Unused())
+	}
+}
+
+type point struct{ X, Y int }
+
+type passThroughConverter struct{}
+
+func (passThroughConverter) ConvertValue(v interface{}) (driver.Value, error) {
+	return v, nil
+}
+
+func TestRecorder_unsupportedValue(t *testing.T) {
+	_, mock, err := sqlmock.NewWithDSN("recorder_unsupported", sqlmock.ValueConverterOption(passThroughConverter{}))
+	require.NoError(t, err)
+
+	var (
+		cassette = bytes.NewBuffer(nil)
+		rec      = dbwrap.NewRecorder(cassette)
+	)
+
+	driverName, err := dbwrap.Register("sqlmock", dbwrap.WithRecorder(rec))
+	require.NoError(t, err)
+
+	db, err := sql.Open(driverName, "recorder_unsupported")
+	require.NoError(t, err)
+
+	mock.ExpectExec("UPDATE t").WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM t").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectClose()
+
+	_, err = db.Exec("UPDATE t SET p = ?", point{X: 1, Y: 2})
+	require.NoError(t, err)
+
+	_, err = db.Exec("DELETE FROM t")
+	require.NoError(t, err)
+
+	require.NoError(t, db.Close())
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.NoError(t, rec.Err())
+
+	// Value that is not a driver.Value does not stop recording.
+	c, err := dbwrap.LoadCassette(bytes.NewReader(cassette.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, c.Interactions, 2)
+	assert.Equal(t, dbwrap.CassetteText("dbwrap_test.point{X:1, Y:2}"), c.Interactions[0].Args[0].Value)
+	assert.Equal(t, "DELETE FROM t", c.Interactions[1].Statement)
+}
+
+func TestReplayer_mismatch(t *testing.T) {
+	c, err := dbwrap.LoadCassette(strings.NewReader(`{"version":1}
+{"seq":1,"operation":"prepare","statement":"SELECT ?"}
+{"seq":2,"operation":"stmt_query","statement":"SELECT ?","args":[{"ordinal":1,"type":"int64","value":"1"}],"columns":[{"name":"a"}],"rows":[[{"type":"int64","value":"1"}]]}
+`))
+	require.NoError(t, err)
+
+	db := sql.OpenDB(dbwrap.NewReplayer(c, dbwrap.ReplayStrict))
+
+	// Query falls back to prepared statement as it was recorded.
+	var v int
+
+	require.NoError(t, db.QueryRow("SELECT ?", 1).Scan(&v))
+	assert.Equal(t, 1, v)
+
+	_, err = db.Exec("DELETE FROM t WHERE id = ?", 1)
+	assert.EqualError(t, err, "dbwrap: unexpected exec in cassette replay\n"+
+		"- expected: end of cassette\n"+
+		"+ actual:   exec DELETE FROM t WHERE id = ? [1]")
+
+	db = sql.OpenDB(dbwrap.NewReplayer(c, dbwrap.ReplayLoose))
+
+	_, err = db.Query("SELECT  ?", 2)
+	assert.EqualError(t, err, "dbwrap: unexpected stmt_query in cassette replay\n"+
+		"- expected: stmt_query SELECT ? [1]\n"+
+		"+ actual:   stmt_query SELECT  ? [2]")
+
+	_, ok := err.(*dbwrap.ReplayMismatchError)
+	assert.True(t, ok)
+
+	_, err = dbwrap.LoadCassette(strings.NewReader(`{"version":2}`))
+	assert.EqualError(t, err, "unsupported cassette version 2")
+}
@@ -0,0 +1,380 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedRows is a snapshot of query result.
+type CachedRows struct {
+	Columns []CassetteColumn  `json:"columns"`
+	Rows    [][]CassetteValue `json:"rows,omitempty"`
+
+	// Tables are referenced by statement, writes to them invalidate the entry.
+	Tables []string `json:"tables,omitempty"`
+
+	// Version is a sequence of ResultCache at the beginning of query.
+	Version int64 `json:"version"`
+
+	Expires time.Time `json:"expires"`
+}
+
+// ResultCacheStorage stores snapshots of query results.
+//
+// Implementation must be safe for concurrent use.
+type ResultCacheStorage interface {
+	Get(key string) (*CachedRows, bool)
+	Set(key string, rows *CachedRows)
+	Delete(key string)
+}
+
+// LRUStorage is an in-memory ResultCacheStorage with least recently used eviction.
+type LRUStorage struct {
+	mu  sync.Mutex
+	lru *lru
+}
+
+// NewLRUStorage creates LRUStorage with a maximum number of entries.
+func NewLRUStorage(capacity int) *LRUStorage {
+	return &LRUStorage{lru: newLRU(capacity, nil)}
+}
+
+// Get returns stored rows.
+func (s *LRUStorage) Get(key string) (*CachedRows, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.lru.get(key)
+	if !ok {
+		return nil, false
+	}
+
+	return v.(*CachedRows), true
+}
+
+// Set stores rows.
+func (s *LRUStorage) Set(key string, rows *CachedRows) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lru.set(key, rows)
+}
+
+// Delete removes rows.
+func (s *LRUStorage) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lru.delete(key)
+}
+
+// Len returns number of stored entries.
+func (s *LRUStorage) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.len()
+}
+
+// ResultCacheConfig controls ResultCache.
+type ResultCacheConfig struct {
+	// TTL is a time to live of cached result, default 1 minute.
+	TTL time.Duration
+
+	// Storage keeps cached results, default is NewLRUStorage(1000).
+	Storage ResultCacheStorage
+
+	// MaxRows limits number of rows in cached result, default 1000.
+	MaxRows int
+
+	// Cacheable is an optional filter of statements.
+	Cacheable func(ctx context.Context, statement string) bool
+}
+
+// ResultCache serves repeated read queries from snapshots of results.
+//
+// Only SELECT statements out of transaction are cached, result is keyed by tenant, statement and arguments.
+// Results with values that are not driver.Value are not cached.
+// Writes with Exec, or with Query of non-SELECT statements, invalidate results of queries referencing
+// same tables, writes in transaction invalidate again on commit or rollback.
+// Statements with unknown tables invalidate all results.
+//
+// Invalidation is tracked in memory, so it only covers writes made by this process.
+type ResultCache struct {
+	config ResultCacheConfig
+
+	mu      sync.Mutex
+	seq     int64
+	all     int64
+	tables  map[string]int64
+	pending map[*txState]*txWrites
+}
+
+// NewResultCache creates ResultCache.
+func NewResultCache(config ResultCacheConfig) *ResultCache {
+	if config.TTL == 0 {
+		config.TTL = time.Minute
+	}
+
+	if config.Storage == nil {
+		config.Storage = NewLRUStorage(1000)
+	}
+
+	if config.MaxRows == 0 {
+		config.MaxRows = 1000
+	}
+
+	return &ResultCache{
+		config:  config,
+		tables:  make(map[string]int64),
+		pending: make(map[*txState]*txWrites),
+	}
+}
+
+// WithResultCache adds result cache to a db wrapper.
+func WithResultCache(rc *ResultCache) Option {
+	return withHook(hook{
+		query: rc.query,
+		exec:  rc.exec,
+		call:  rc.call,
+	})
+}
+
+// Invalidate discards cached results of queries referencing tables.
+//
+// All results are discarded if no tables are provided.
+func (rc *ResultCache) Invalidate(tables ...string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.invalidate(tables)
+}
+
+func (rc *ResultCache) invalidate(tables []string) {
+	rc.seq++
+
+	if len(tables) == 0 {
+		rc.all = rc.seq
+
+		return
+	}
+
+	for _, t := range tables {
+		rc.tables[strings.ToLower(t)] = rc.seq
+	}
+}
+
+// version returns current sequence.
+func (rc *ResultCache) version() int64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.seq
+}
+
+// fresh checks that cached rows were not invalidated.
+func (rc *ResultCache) fresh(cr *CachedRows) bool {
+	if time.Now().After(cr.Expires) {
+		return false
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.all > cr.Version {
+		return false
+	}
+
+	for _, t := range cr.Tables {
+		if rc.tables[t] > cr.Version {
+			return false
+		}
+	}
+
+	return true
+}
+
+// write invalidates tables of a write statement.
+func (rc *ResultCache) write(ctx context.Context, tokens []sqlToken) {
+	switch sqlVerb(tokens) {
+	case "SELECT", "SHOW", "EXPLAIN", "SET", "USE":
+		return
+	}
+
+	tables := sqlTables(tokens)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.invalidate(tables)
+
+	if tx := txFromContext(ctx); tx != nil {
+		w := rc.pending[tx]
+		if w == nil {
+			w = &txWrites{}
+			rc.pending[tx] = w
+		}
+
+		if len(tables) == 0 {
+			w.all = true
+		} else {
+			w.tables = append(w.tables, tables...)
+		}
+	}
+}
+
+// txWrites are tables written in transaction.
+type txWrites struct {
+	all    bool
+	tables []string
+}
+
+func (rc *ResultCache) call(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	next func(ctx context.Context) error,
+) error {
+	err := next(ctx)
+
+	if operation != Commit && operation != Rollback {
+		return err
+	}
+
+	tx := txFromContext(ctx)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if w, ok := rc.pending[tx]; ok {
+		delete(rc.pending, tx)
+
+		if w.all {
+			rc.invalidate(nil)
+		} else {
+			rc.invalidate(w.tables)
+		}
+	}
+
+	return err
+}
+
+func (rc *ResultCache) exec(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+	next func(ctx context.Context) (driver.Result, error),
+) (driver.Result, error) {
+	res, err := next(ctx)
+
+	rc.write(ctx, parseStatement(ctx, statement).lex())
+
+	return res, err
+}
+
+func (rc *ResultCache) query(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+	next func(ctx context.Context) (driver.Rows, error),
+) (driver.Rows, error) {
+	tokens := parseStatement(ctx, statement).lex()
+
+	if sqlVerb(tokens) != "SELECT" {
+		rows, err := next(ctx)
+
+		rc.write(ctx, tokens)
+
+		return rows, err
+	}
+
+	if InTx(ctx) || (rc.config.Cacheable != nil && !rc.config.Cacheable(ctx, statement)) {
+		return next(ctx)
+	}
+
+	key := cacheKey(Tenant(ctx), statement, args)
+
+	if cr, ok := rc.config.Storage.Get(key); ok {
+		if rc.fresh(cr) {
+			return &replayRows{i: &Interaction{Columns: cr.Columns, Rows: cr.Rows}}, nil
+		}
+
+		rc.config.Storage.Delete(key)
+	}
+
+	version := rc.version()
+
+	rows, err := next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := &CachedRows{
+		Columns: cassetteColumns(rows),
+		Tables:  sqlTables(tokens),
+		Version: version,
+	}
+	done := false
+
+	return decorateRows(rows,
+		func(dest []driver.Value) error {
+			err := rows.Next(dest)
+
+			switch {
+			case done:
+			case err == nil:
+				if len(cr.Rows) == rc.config.MaxRows {
+					done = true
+
+					break
+				}
+
+				row := make([]driver.NamedValue, len(dest))
+				for j, v := range dest {
+					// Values of driver types can not be replayed as is, result is not cached.
+					if !driver.IsValue(v) {
+						done = true
+
+						return err
+					}
+
+					row[j].Value = v
+				}
+
+				cr.Rows = append(cr.Rows, cassetteValues(row))
+			case err == io.EOF:
+				done = true
+				cr.Expires = time.Now().Add(rc.config.TTL)
+
+				rc.config.Storage.Set(key, cr)
+			default:
+				done = true
+			}
+
+			return err
+		},
+		rows.Close,
+	), nil
+}
+
+// cacheKey builds a key of statement with arguments, tenant separates results of same statement
+// routed to different schemas, see WithTenantRouting.
+func cacheKey(tenant, statement string, args []driver.NamedValue) string {
+	b := strings.Builder{}
+	b.WriteString(tenant)
+	b.WriteString("\x00")
+	b.WriteString(statement)
+
+	for _, a := range args {
+		fmt.Fprintf(&b, "\x00%s:%d:%T:%v", a.Name, a.Ordinal, a.Value, a.Value)
+	}
+
+	return b.String()
+}
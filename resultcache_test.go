@@ -0,0 +1,279 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithResultCache(t *testing.T) {
+	ctx := context.Background()
+	f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	val := int64(1)
+
+	f.Query = func(ctx context.Context, statement string, args []driver.NamedValue) (*dbwraptest.Rows, error) {
+		rows := dbwraptest.NewRows("a").AddRow(val)
+		rows.Columns[0].DatabaseTypeName = "BIGINT"
+		rows.Columns[0].Nullable = true
+
+		return rows, nil
+	}
+
+	storage := dbwrap.NewLRUStorage(10)
+	rc := dbwrap.NewResultCache(dbwrap.ResultCacheConfig{Storage: storage})
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithResultCache(rc)))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	queries := func() int {
+		cnt := 0
+
+		for _, c := range f.Calls() {
+			if c.Method == "Conn.QueryContext" {
+				cnt++
+			}
+		}
+
+		return cnt
+	}
+
+	get := func() int64 {
+		rows, err := db.QueryContext(ctx, "SELECT a FROM t WHERE b = ?", 1)
+		require.NoError(t, err)
+
+		types, err := rows.ColumnTypes()
+		require.NoError(t, err)
+		assert.Equal(t, "BIGINT", types[0].DatabaseTypeName())
+
+		nullable, ok := types[0].Nullable()
+		assert.True(t, ok)
+		assert.True(t, nullable)
+
+		var a int64
+
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Scan(&a))
+		require.False(t, rows.Next())
+		require.NoError(t, rows.Close())
+
+		return a
+	}
+
+	assert.Equal(t, int64(1), get())
+	assert.Equal(t, int64(1), get())
+	assert.Equal(t, 1, queries())
+	assert.Equal(t, 1, storage.Len())
+
+	// Write to another table keeps cache.
+	_, err := db.ExecContext(ctx, "UPDATE t2 SET a = 2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), get())
+	assert.Equal(t, 1, queries())
+
+	// Write to the table invalidates cache.
+	val = 2
+	_, err = db.ExecContext(ctx, "UPDATE t SET a = 2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), get())
+	assert.Equal(t, 2, queries())
+
+	// Transaction bypasses cache, writes are invalidated again on commit.
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM t")
+	require.NoError(t, err)
+
+	rows, err := tx.QueryContext(ctx, "SELECT a FROM t WHERE b = ?", 1)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+	assert.Equal(t, 3, queries())
+
+	assert.Equal(t, int64(2), get())
+	assert.Equal(t, 4, queries())
+
+	val = 3
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, int64(3), get())
+	assert.Equal(t, 5, queries())
+
+	rc.Invalidate()
+	assert.Equal(t, int64(3), get())
+	assert.Equal(t, 6, queries())
+}
+
+type money int64
+
+func TestWithResultCache_columnTypes(t *testing.T) {
+	ctx := context.Background()
+	f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+
+	f.Query = func(ctx context.Context, statement string, args []driver.NamedValue) (*dbwraptest.Rows, error) {
+		rows := dbwraptest.NewRows("a", "b").AddRow(int64(1), int64(2))
+		rows.Columns[0].ScanType = reflect.TypeOf(int64(0))
+		rows.Columns[1].ScanType = reflect.TypeOf(money(0))
+		rows.Columns[1].DatabaseTypeName = "MONEY"
+		rows.Columns[1].Precision = 10
+		rows.Columns[1].Scale = 2
+
+		return rows, nil
+	}
+
+	rc := dbwrap.NewResultCache(dbwrap.ResultCacheConfig{})
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithResultCache(rc)))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	type columnType struct {
+		Name             string
+		DatabaseTypeName string
+		ScanType         reflect.Type
+		Length           int64
+		LengthOk         bool
+		Nullable         bool
+		NullableOk       bool
+		Precision, Scale int64
+		DecimalSizeOk    bool
+	}
+
+	columnTypes := func() []columnType {
+		rows, err := db.QueryContext(ctx, "SELECT a, b FROM t")
+		require.NoError(t, err)
+
+		types, err := rows.ColumnTypes()
+		require.NoError(t, err)
+
+		var res []columnType
+
+		for _, ct := range types {
+			c := columnType{Name: ct.Name(), DatabaseTypeName: ct.DatabaseTypeName(), ScanType: ct.ScanType()}
+			c.Length, c.LengthOk = ct.Length()
+			c.Nullable, c.NullableOk = ct.Nullable()
+			c.Precision, c.Scale, c.DecimalSizeOk = ct.DecimalSize()
+			res = append(res, c)
+		}
+
+		cnt := 0
+		for rows.Next() {
+			cnt++
+		}
+
+		assert.Equal(t, 1, cnt)
+		require.NoError(t, rows.Err())
+		require.NoError(t, rows.Close())
+
+		return res
+	}
+
+	miss := columnTypes()
+	hit := columnTypes()
+
+	queries := 0
+
+	for _, m := range f.Methods() {
+		if m == "Conn.QueryContext" {
+			queries++
+		}
+	}
+
+	assert.Equal(t, 1, queries)
+	assert.Equal(t, reflect.TypeOf(money(0)), miss[1].ScanType)
+	assert.Equal(t, miss, hit)
+}
+
+func TestWithResultCache_tenants(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		f      = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		schema string
+	)
+
+	f.Exec = func(ctx context.Context, statement string, args []driver.NamedValue) (driver.Result, error) {
+		schema = statement
+
+		return driver.RowsAffected(0), nil
+	}
+
+	f.Query = func(ctx context.Context, statement string, args []driver.NamedValue) (*dbwraptest.Rows, error) {
+		return dbwraptest.NewRows("a").AddRow(schema), nil
+	}
+
+	rc := dbwrap.NewResultCache(dbwrap.ResultCacheConfig{})
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(),
+		dbwrap.WithTenantRouting(dbwrap.TenantConfig{}), dbwrap.WithResultCache(rc)))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	get := func(tenant string) string {
+		rows, err := db.QueryContext(dbwrap.TenantContext(ctx, tenant), "SELECT a FROM t")
+		require.NoError(t, err)
+
+		var a string
+
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Scan(&a))
+		require.False(t, rows.Next())
+		require.NoError(t, rows.Close())
+
+		return a
+	}
+
+	assert.Equal(t, `SET search_path TO "a"`, get("a"))
+	assert.Equal(t, `SET search_path TO "b"`, get("b"))
+	assert.Equal(t, `SET search_path TO "a"`, get("a"))
+
+	queries := 0
+
+	for _, m := range f.Methods() {
+		if m == "Conn.QueryContext" {
+			queries++
+		}
+	}
+
+	assert.Equal(t, 2, queries)
+}
+
+func TestWithResultCache_notValue(t *testing.T) {
+	ctx := context.Background()
+	f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+
+	f.Query = func(ctx context.Context, statement string, args []driver.NamedValue) (*dbwraptest.Rows, error) {
+		return dbwraptest.NewRows("a").AddRow(point{1, 2}), nil
+	}
+
+	storage := dbwrap.NewLRUStorage(10)
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(),
+		dbwrap.WithResultCache(dbwrap.NewResultCache(dbwrap.ResultCacheConfig{Storage: storage}))))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	for i := 0; i < 2; i++ {
+		rows, err := db.QueryContext(ctx, "SELECT a FROM t")
+		require.NoError(t, err)
+
+		var a interface{}
+
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Scan(&a))
+		assert.Equal(t, point{1, 2}, a)
+		require.False(t, rows.Next())
+		require.NoError(t, rows.Close())
+	}
+
+	assert.Equal(t, 0, storage.Len())
+}
@@ -1,15 +1,22 @@
 package dbwrap
 
 import (
+	"bufio"
 	"context"
+	"os"
 	"path"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 const (
 	skipCallers = 6
 	stackSize   = 30
+
+	// callerDepth is a default number of inspected stack frames of CallerFrame.
+	callerDepth = 100
 )
 
 type callerCtxKey struct{}
@@ -20,26 +27,179 @@ func WithCaller(ctx context.Context, caller string) context.Context {
 }
 
 // CallerCtx checks context for a pre-defined caller value or returns caller from runtime stack.
+//
+// Without skipPackages, caller of operation is resolved once with packages of WithCallerSkip
+// and shared by middlewares and finalizers of operation.
 func CallerCtx(ctx context.Context, skipPackages ...string) string {
 	if caller, ok := ctx.Value(callerCtxKey{}).(string); ok {
 		return caller
 	}
 
+	if p, ok := ctx.Value(statementCtxKey{}).(*opState); ok && len(skipPackages) == 0 {
+		return p.callerName()
+	}
+
 	return Caller(skipPackages...)
 }
 
+// skipRegistry holds []string of globally skipped package patterns.
+var skipRegistry atomic.Value
+
+// SkipCallerPackages registers packages that are skipped by Caller, CallerCtx and CallerFrame in the whole process,
+// so that skip lists do not have to be repeated.
+//
+// Patterns are import paths or globs of path.Match, for example "github.com/jmoiron/*",
+// trailing "/..." also matches subpackages, for example "gorm.io/...".
+func SkipCallerPackages(patterns ...string) {
+	skipMu.Lock()
+	defer skipMu.Unlock()
+
+	prev, _ := skipRegistry.Load().([]string)
+	skipRegistry.Store(append(append([]string(nil), prev...), patterns...))
+
+	callers.reset()
+}
+
+var skipMu sync.Mutex
+
+// WithCallerSkip sets packages that are skipped by CallerCtx for operations of a db wrapper,
+// patterns are the same as in SkipCallerPackages.
+func WithCallerSkip(patterns ...string) Option {
+	return func(o *Options) {
+		o.callerSkip = append(o.callerSkip, patterns...)
+	}
+}
+
+// skippedPackage checks if package is skipped globally or with patterns.
+func skippedPackage(p string, patterns []string) bool {
+	if p == "database/sql" || p == "github.com/bool64/dbwrap" {
+		return true
+	}
+
+	global, _ := skipRegistry.Load().([]string)
+
+	return matchPackage(p, patterns) || matchPackage(p, global)
+}
+
+// matchPackage checks if package matches any of patterns.
+func matchPackage(p string, patterns []string) bool {
+	for _, pattern := range patterns {
+		switch {
+		case p == pattern:
+			return true
+		case strings.HasSuffix(pattern, "/..."):
+			prefix := strings.TrimSuffix(pattern, "/...")
+
+			if p == prefix || strings.HasPrefix(p, prefix+"/") {
+				return true
+			}
+		case strings.ContainsAny(pattern, "*?["):
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // Caller returns name and package of closest parent function
 // that does not belong to skipped packages.
 //
 // For example the result could be
 //
 //	pressly/goose.MySQLDialect.dbVersionQuery
+//
+// Results are cached by program counters of the stack, so that repeated calls from
+// the same place do not allocate. Only 30 stack frames are inspected, use CallerFrame for deeper stacks.
 func Caller(skipPackages ...string) string {
-	p := ""
-	pc := make([]uintptr, stackSize)
+	var pc [stackSize]uintptr
+
+	runtime.Callers(skipCallers, pc[:])
+
+	if caller, ok := callers.get(&pc, skipPackages); ok {
+		return caller
+	}
+
+	// Copy keeps pc on stack for the fast path.
+	caller := resolveCaller(append([]uintptr(nil), pc[:]...), skipPackages)
+	callers.set(&pc, skipPackages, caller)
+
+	return caller
+}
+
+// maxCachedStacks limits number of stacks in callers cache, cache is cleared when limit is reached.
+const maxCachedStacks = 10000
 
-	runtime.Callers(skipCallers, pc)
+// callers caches results of Caller.
+var callers = callerCache{stacks: make(map[[stackSize]uintptr]*callerEntry)}
 
+// callerCache maps program counters of stack to resolved callers.
+type callerCache struct {
+	mu     sync.RWMutex
+	stacks map[[stackSize]uintptr]*callerEntry
+}
+
+// callerEntry is an immutable list of callers of a stack resolved with different skipped packages.
+type callerEntry struct {
+	skipPackages []string
+	caller       string
+	next         *callerEntry
+}
+
+func (c *callerCache) get(pc *[stackSize]uintptr, skipPackages []string) (string, bool) {
+	c.mu.RLock()
+	e := c.stacks[*pc]
+	c.mu.RUnlock()
+
+	for ; e != nil; e = e.next {
+		if equalStrings(e.skipPackages, skipPackages) {
+			return e.caller, true
+		}
+	}
+
+	return "", false
+}
+
+func (c *callerCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stacks = make(map[[stackSize]uintptr]*callerEntry)
+}
+
+func (c *callerCache) set(pc *[stackSize]uintptr, skipPackages []string, caller string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.stacks) >= maxCachedStacks {
+		c.stacks = make(map[[stackSize]uintptr]*callerEntry)
+	}
+
+	c.stacks[*pc] = &callerEntry{
+		skipPackages: append([]string(nil), skipPackages...),
+		caller:       caller,
+		next:         c.stacks[*pc],
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolveCaller finds caller in program counters of stack.
+func resolveCaller(pc []uintptr, skipPackages []string) string {
+	p := ""
 	frames := runtime.CallersFrames(pc)
 
 	for {
@@ -56,32 +216,197 @@ func Caller(skipPackages ...string) string {
 			continue
 		}
 
-		parts := strings.Split(fn, "/")
-		parts[len(parts)-1] = strings.Split(parts[len(parts)-1], ".")[0]
-		p = strings.Join(parts, "/")
+		p = funcPackage(fn)
 
-		if p == "database/sql" || p == "github.com/bool64/dbwrap" {
+		if skippedPackage(p, skipPackages) {
 			continue
 		}
 
-		skip := false
+		p = path.Base(path.Dir(fn)) + "/" + path.Base(fn)
 
-		for _, sp := range skipPackages {
-			if p == sp {
-				skip = true
+		break
+	}
 
-				break
+	return p
+}
+
+// CallerInfo describes a caller function.
+type CallerInfo struct {
+	// Function is a full name of function, for example "github.com/pressly/goose.MySQLDialect.dbVersionQuery".
+	Function string
+
+	// Package is a full import path of function package, for example "github.com/pressly/goose".
+	Package string
+
+	File string
+	Line int
+}
+
+// String returns short name of caller in the format of Caller, for example "pressly/goose.MySQLDialect.dbVersionQuery".
+func (c CallerInfo) String() string {
+	if c.Function == "" {
+		return ""
+	}
+
+	return path.Base(path.Dir(c.Function)) + "/" + path.Base(c.Function)
+}
+
+// CallerOption configures CallerFrame.
+type CallerOption func(o *callerOptions)
+
+type callerOptions struct {
+	depth         int
+	skipPackages  []string
+	skipPrefixes  []string
+	skipGenerated bool
+}
+
+// CallerDepth limits number of inspected stack frames, default 100, minimum 1.
+func CallerDepth(depth int) CallerOption {
+	return func(o *callerOptions) {
+		o.depth = depth
+	}
+}
+
+// CallerSkipPackages skips functions of packages, patterns are the same as in SkipCallerPackages.
+func CallerSkipPackages(packages ...string) CallerOption {
+	return func(o *callerOptions) {
+		o.skipPackages = append(o.skipPackages, packages...)
+	}
+}
+
+// CallerSkipPrefixes skips functions of packages with import paths that start with any of prefixes,
+// for example "github.com/jmoiron/" skips all packages of an organization.
+func CallerSkipPrefixes(prefixes ...string) CallerOption {
+	return func(o *callerOptions) {
+		o.skipPrefixes = append(o.skipPrefixes, prefixes...)
+	}
+}
+
+// CallerSkipGenerated skips functions of generated files, for example query builders or mocks.
+//
+// File is considered generated if it has "// Code generated ... DO NOT EDIT." header or name
+// with conventional suffix, such as "_gen.go" or ".pb.go".
+func CallerSkipGenerated() CallerOption {
+	return func(o *callerOptions) {
+		o.skipGenerated = true
+	}
+}
+
+// CallerFrame returns closest parent function that does not belong to database/sql,
+// this package or skipped packages.
+//
+// Empty CallerInfo is returned if there is no such function within inspected depth.
+func CallerFrame(options ...CallerOption) CallerInfo {
+	o := callerOptions{depth: callerDepth}
+
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	if o.depth < 1 {
+		o.depth = 1
+	}
+
+	pc := make([]uintptr, o.depth)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+
+	for {
+		frame, more := frames.Next()
+
+		if fn := frame.Function; fn != "" && !strings.Contains(fn, "{") {
+			info := CallerInfo{Function: fn, Package: funcPackage(fn), File: frame.File, Line: frame.Line}
+
+			if !o.skips(info) {
+				return info
 			}
 		}
 
-		if skip {
-			continue
+		if !more {
+			return CallerInfo{}
+		}
+	}
+}
+
+// funcPackage returns import path of package from full function name.
+func funcPackage(fn string) string {
+	slash := strings.LastIndex(fn, "/")
+
+	if dot := strings.Index(fn[slash+1:], "."); dot >= 0 {
+		return fn[:slash+1+dot]
+	}
+
+	return fn
+}
+
+func (o callerOptions) skips(info CallerInfo) bool {
+	p := info.Package
+
+	if p == "runtime" || skippedPackage(p, o.skipPackages) {
+		return true
+	}
+
+	for _, sp := range o.skipPrefixes {
+		if strings.HasPrefix(p, sp) {
+			return true
 		}
+	}
 
-		p = path.Base(path.Dir(fn)) + "/" + path.Base(fn)
+	return o.skipGenerated && isGeneratedFile(info.File)
+}
 
-		break
+// generatedFiles caches results of isGeneratedFile.
+var generatedFiles sync.Map
+
+// isGeneratedFile checks if file has generated code.
+func isGeneratedFile(file string) bool {
+	if file == "" {
+		return false
 	}
 
-	return p
+	for _, suffix := range []string{"_gen.go", "_generated.go", ".gen.go", ".pb.go", "_mock.go"} {
+		if strings.HasSuffix(file, suffix) {
+			return true
+		}
+	}
+
+	if strings.HasPrefix(path.Base(file), "zz_generated") {
+		return true
+	}
+
+	if g, ok := generatedFiles.Load(file); ok {
+		return g.(bool)
+	}
+
+	g := hasGeneratedHeader(file)
+	generatedFiles.Store(file, g)
+
+	return g
+}
+
+// hasGeneratedHeader checks if file has a "// Code generated ... DO NOT EDIT." line before package clause.
+func hasGeneratedHeader(file string) bool {
+	f, err := os.Open(file) //nolint:gosec // File name comes from runtime.
+	if err != nil {
+		return false
+	}
+
+	defer f.Close() //nolint:errcheck // Read only.
+
+	s := bufio.NewScanner(f)
+
+	for s.Scan() {
+		line := s.Text()
+
+		if strings.HasPrefix(line, "// Code generated ") && strings.HasSuffix(line, " DO NOT EDIT.") {
+			return true
+		}
+
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,156 @@
+package dbwrap
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSkipCallerPackages(t *testing.T) {
+	prev, _ := skipRegistry.Load().([]string)
+
+	defer func() {
+		skipRegistry.Store(prev)
+		callers.reset()
+	}()
+
+	if c := CallerFrame(); c.Function != "testing.tRunner" {
+		t.Fatalf("unexpected caller frame: %v", c)
+	}
+
+	SkipCallerPackages("test*")
+
+	if c := CallerFrame(); c != (CallerInfo{}) {
+		t.Fatalf("unexpected caller frame: %v", c)
+	}
+}
+
+func TestMatchPackage(t *testing.T) {
+	patterns := []string{"gorm.io/...", "github.com/jmoiron/*", "database/sql"}
+
+	for p, expected := range map[string]bool{
+		"gorm.io":                          true,
+		"gorm.io/gorm/clause":              true,
+		"gorm.io2":                         false,
+		"github.com/jmoiron/sqlx":          true,
+		"github.com/jmoiron/sqlx/reflectx": false,
+		"database/sql":                     true,
+		"database/sql/driver":              false,
+	} {
+		if matchPackage(p, patterns) != expected {
+			t.Errorf("unexpected match of %s", p)
+		}
+	}
+}
+
+func TestIsGeneratedFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "dbwrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if err := os.Remove(f.Name()); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if _, err := f.WriteString("// Package foo.\n// Code generated by foo. DO NOT EDIT.\n\npackage foo\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for file, expected := range map[string]bool{
+		f.Name():               true,
+		"runtime.go":           false,
+		"runtime_gen_test.go":  true,
+		"api.pb.go":            true,
+		"zz_generated.deep.go": true,
+		"":                     false,
+	} {
+		if isGeneratedFile(file) != expected {
+			t.Errorf("unexpected generated flag of %s", file)
+		}
+	}
+}
+
+// baselineCaller is Caller without cache as it was before caching.
+func baselineCaller(skipPackages ...string) string {
+	p := ""
+	pc := make([]uintptr, stackSize)
+
+	runtime.Callers(skipCallers, pc)
+
+	frames := runtime.CallersFrames(pc)
+
+	for {
+		frame, more := frames.Next()
+
+		if !more {
+			break
+		}
+
+		fn := frame.Function
+
+		// Skip unnamed literals.
+		if fn == "" || strings.Contains(fn, "{") {
+			continue
+		}
+
+		parts := strings.Split(fn, "/")
+		parts[len(parts)-1] = strings.Split(parts[len(parts)-1], ".")[0]
+		p = strings.Join(parts, "/")
+
+		if p == "database/sql" || p == "github.com/bool64/dbwrap" {
+			continue
+		}
+
+		skip := false
+
+		for _, sp := range skipPackages {
+			if p == sp {
+				skip = true
+
+				break
+			}
+		}
+
+		if skip {
+			continue
+		}
+
+		p = path.Base(path.Dir(fn)) + "/" + path.Base(fn)
+
+		break
+	}
+
+	return p
+}
+
+// callersAt returns results of Caller and baselineCaller from depth frames of recursion.
+func callersAt(depth int, skipPackages ...string) (string, string) {
+	if depth > 0 {
+		return callersAt(depth-1, skipPackages...)
+	}
+
+	return Caller(skipPackages...), baselineCaller(skipPackages...)
+}
+
+func TestCaller_baseline(t *testing.T) {
+	for depth := 0; depth < 40; depth++ {
+		for _, skip := range [][]string{nil, {"testing"}} {
+			for i := 0; i < 2; i++ {
+				caller, baseline := callersAt(depth, skip...)
+				if caller != baseline {
+					t.Fatalf("unexpected caller at depth %d: %q, baseline %q", depth, caller, baseline)
+				}
+			}
+		}
+	}
+}
@@ -2,10 +2,15 @@ package dbwrap_test
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path"
 	"testing"
 
 	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func BenchmarkCaller(b *testing.B) {
@@ -21,3 +26,122 @@ func TestCallerCtx(t *testing.T) {
 
 	assert.Equal(t, "test", dbwrap.CallerCtx(ctx, "abc"))
 }
+
+func TestCallerFrame(t *testing.T) {
+	c := dbwrap.CallerFrame()
+	assert.Equal(t, "github.com/bool64/dbwrap_test.TestCallerFrame", c.Function)
+	assert.Equal(t, "github.com/bool64/dbwrap_test", c.Package)
+	assert.Equal(t, "runtime_test.go", path.Base(c.File))
+	assert.NotZero(t, c.Line)
+	assert.Equal(t, "bool64/dbwrap_test.TestCallerFrame", c.String())
+
+	c = generatedCaller()
+	assert.Equal(t, "github.com/bool64/dbwrap_test.generatedCaller", c.Function)
+	assert.Equal(t, "runtime_gen_test.go", path.Base(c.File))
+
+	c = generatedCaller(dbwrap.CallerSkipGenerated())
+	assert.Equal(t, "github.com/bool64/dbwrap_test.TestCallerFrame", c.Function)
+
+	c = dbwrap.CallerFrame(dbwrap.CallerSkipPrefixes("github.com/bool64/"))
+	assert.Equal(t, "testing", c.Package)
+
+	c = dbwrap.CallerFrame(dbwrap.CallerSkipPackages("github.com/bool64/dbwrap_test", "testing"))
+	assert.Equal(t, dbwrap.CallerInfo{}, c)
+
+	c = dbwrap.CallerFrame(dbwrap.CallerSkipPackages("github.com/bool64/dbwrap_test"), dbwrap.CallerDepth(1))
+	assert.Equal(t, dbwrap.CallerInfo{}, c)
+
+	c = dbwrap.CallerFrame(dbwrap.CallerSkipPackages("github.com/bool64/dbwrap_test"), dbwrap.CallerDepth(-1))
+	assert.Equal(t, dbwrap.CallerInfo{}, c)
+	assert.Equal(t, "", c.String())
+}
+
+func TestCaller(t *testing.T) {
+	var callers []string
+
+	f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithMiddleware(func(
+		ctx context.Context,
+		operation dbwrap.Operation,
+		statement string,
+		args []driver.NamedValue,
+	) (context.Context, func(error)) {
+		callers = append(callers, dbwrap.Caller(), dbwrap.Caller("github.com/bool64/dbwrap_test"))
+
+		return ctx, nil
+	})))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	for i := 0; i < 3; i++ {
+		_, err := db.Exec("DELETE FROM t")
+		require.NoError(t, err)
+	}
+
+	require.Len(t, callers, 6)
+	assert.Equal(t, "bool64/dbwrap_test.TestCaller", callers[0])
+	assert.Equal(t, "./testing.tRunner", callers[1])
+
+	for i := 2; i < 6; i++ {
+		assert.Equal(t, callers[i%2], callers[i])
+	}
+}
+
+// deepCaller calls Caller from depth frames of recursion.
+func deepCaller(depth int) string {
+	if depth > 0 {
+		return deepCaller(depth - 1)
+	}
+
+	return dbwrap.Caller("github.com/bool64/dbwrap_test")
+}
+
+// deepCallerFrame calls CallerFrame from depth frames of recursion.
+func deepCallerFrame(depth int) dbwrap.CallerInfo {
+	if depth > 0 {
+		return deepCallerFrame(depth - 1)
+	}
+
+	return dbwrap.CallerFrame(dbwrap.CallerSkipPackages("github.com/bool64/dbwrap_test"))
+}
+
+func TestCaller_deepStack(t *testing.T) {
+	assert.Equal(t, "./testing.tRunner", deepCaller(10))
+	// Caller inspects 30 stack frames.
+	assert.NotEqual(t, "./testing.tRunner", deepCaller(50))
+	assert.Equal(t, "testing.tRunner", deepCallerFrame(50).Function)
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	var callers []string
+
+	mw := func(
+		ctx context.Context,
+		operation dbwrap.Operation,
+		statement string,
+		args []driver.NamedValue,
+	) (context.Context, func(error)) {
+		callers = append(callers, dbwrap.CallerCtx(ctx))
+
+		return ctx, func(err error) {
+			callers = append(callers, dbwrap.CallerCtx(ctx))
+		}
+	}
+
+	f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(),
+		dbwrap.WithCallerSkip("github.com/bool64/*"),
+		dbwrap.WithMiddleware(mw, mw),
+	))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err := db.Exec("DELETE FROM t")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"./testing.tRunner", "./testing.tRunner", "./testing.tRunner", "./testing.tRunner"}, callers)
+}
@@ -0,0 +1,472 @@
+package dbwrap
+
+import (
+	"strings"
+)
+
+// sqlTokenKind enumerates kinds of SQL tokens.
+type sqlTokenKind int
+
+const (
+	sqlWord        sqlTokenKind = iota // Unquoted identifier or keyword.
+	sqlIdent                           // Quoted identifier.
+	sqlString                          // String literal.
+	sqlNumber                          // Numeric literal.
+	sqlPlaceholder                     // Argument placeholder.
+	sqlComment                         // Comment.
+	sqlPunct                           // Operator or punctuation.
+)
+
+// sqlToken is a lexeme of SQL statement.
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// keyword returns upper case text of a word token or empty string.
+func (t sqlToken) keyword() string {
+	if t.kind != sqlWord {
+		return ""
+	}
+
+	return strings.ToUpper(t.text)
+}
+
+// is checks if token is punctuation with text.
+func (t sqlToken) is(punct string) bool {
+	return t.kind == sqlPunct && t.text == punct
+}
+
+// lexSQL splits statement into tokens.
+//
+// Lexer is tolerant to dialects, it recognizes quoting of MySQL, PostgreSQL and SQLite,
+// unterminated literals and comments end with statement.
+//
+// Backslash is an escape in PostgreSQL E'...' strings, other strings follow standard quoting
+// unless that leaves a string unterminated, then backslash escapes of MySQL are assumed.
+func lexSQL(s string) []sqlToken {
+	tokens, terminated := lexSQLEscapes(s, false)
+	if !terminated {
+		if mysql, terminated := lexSQLEscapes(s, true); terminated {
+			return mysql
+		}
+	}
+
+	return tokens
+}
+
+// lexSQLEscapes splits statement into tokens and reports if string literals are terminated,
+// backslash is an escape in all string literals if enabled.
+func lexSQLEscapes(s string, backslash bool) ([]sqlToken, bool) {
+	var (
+		tokens     []sqlToken
+		terminated = true
+	)
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		start := i
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+
+			continue
+		case c == '-' && strings.HasPrefix(s[i:], "--"):
+			i = lineEnd(s, i)
+			tokens = append(tokens, sqlToken{kind: sqlComment, text: s[start:i]})
+		case c == '/' && strings.HasPrefix(s[i:], "/*"):
+			if end := strings.Index(s[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(s)
+			}
+
+			tokens = append(tokens, sqlToken{kind: sqlComment, text: s[start:i]})
+		case c == '\'':
+			var ok bool
+
+			i, ok = quoteEnd(s, i, '\'', backslash)
+			terminated = terminated && ok
+			tokens = append(tokens, sqlToken{kind: sqlString, text: s[start:i]})
+		case (c == 'E' || c == 'e') && i+1 < len(s) && s[i+1] == '\'':
+			var ok bool
+
+			i, ok = quoteEnd(s, i+1, '\'', true)
+			terminated = terminated && ok
+			tokens = append(tokens, sqlToken{kind: sqlString, text: s[start:i]})
+		case c == '"' || c == '`':
+			i, _ = quoteEnd(s, i, c, false)
+			tokens = append(tokens, sqlToken{kind: sqlIdent, text: s[start:i]})
+		case c == '$':
+			i = dollarEnd(s, i)
+
+			kind := sqlString
+			if i-start == 1 || isDigit(s[start+1]) {
+				kind = sqlPlaceholder
+			}
+
+			tokens = append(tokens, sqlToken{kind: kind, text: s[start:i]})
+		case c == '?' && i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '&'):
+			// PostgreSQL jsonb operators ?| and ?&.
+			i += 2
+			tokens = append(tokens, sqlToken{kind: sqlPunct, text: s[start:i]})
+		case c == '?':
+			i++
+			tokens = append(tokens, sqlToken{kind: sqlPlaceholder, text: s[start:i]})
+		case (c == ':' || c == '@') && i+1 < len(s) && isWordStart(s[i+1]):
+			i = wordEnd(s, i+1)
+			tokens = append(tokens, sqlToken{kind: sqlPlaceholder, text: s[start:i]})
+		case isDigit(c) || (c == '.' && i+1 < len(s) && isDigit(s[i+1])):
+			i = wordEnd(s, i+1)
+
+			// Fraction and exponent.
+			for i < len(s) && (s[i] == '.' || ((s[i] == '+' || s[i] == '-') && (s[i-1] == 'e' || s[i-1] == 'E'))) {
+				i = wordEnd(s, i+1)
+			}
+
+			tokens = append(tokens, sqlToken{kind: sqlNumber, text: s[start:i]})
+		case isWordStart(c):
+			i = wordEnd(s, i+1)
+			tokens = append(tokens, sqlToken{kind: sqlWord, text: s[start:i]})
+		default:
+			i++
+
+			if i < len(s) {
+				switch s[start : i+1] {
+				case "<=", ">=", "<>", "!=", "||", "::", "==":
+					i++
+				}
+			}
+
+			tokens = append(tokens, sqlToken{kind: sqlPunct, text: s[start:i]})
+		}
+	}
+
+	return tokens, terminated
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func wordEnd(s string, i int) int {
+	for i < len(s) && (isWordStart(s[i]) || isDigit(s[i]) || s[i] == '$') {
+		i++
+	}
+
+	return i
+}
+
+func lineEnd(s string, i int) int {
+	if end := strings.IndexByte(s[i:], '\n'); end >= 0 {
+		return i + end
+	}
+
+	return len(s)
+}
+
+// quoteEnd returns position after closing quote and reports if it is found, doubled quote is an escape.
+func quoteEnd(s string, i int, quote byte, backslash bool) (int, bool) {
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+
+				continue
+			}
+
+			return i + 1, true
+		}
+	}
+
+	return len(s), false
+}
+
+// dollarEnd returns position after positional placeholder or dollar-quoted string.
+func dollarEnd(s string, i int) int {
+	j := i + 1
+
+	if j < len(s) && isDigit(s[j]) {
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+
+		return j
+	}
+
+	for j < len(s) && (isWordStart(s[j]) || isDigit(s[j])) {
+		j++
+	}
+
+	if j >= len(s) || s[j] != '$' {
+		return i + 1
+	}
+
+	tag := s[i : j+1]
+
+	if end := strings.Index(s[j+1:], tag); end >= 0 {
+		return j + 1 + end + len(tag)
+	}
+
+	return len(s)
+}
+
+// significant returns tokens without comments.
+func significant(tokens []sqlToken) []sqlToken {
+	res := make([]sqlToken, 0, len(tokens))
+
+	for _, t := range tokens {
+		if t.kind != sqlComment {
+			res = append(res, t)
+		}
+	}
+
+	return res
+}
+
+// sqlVerb returns upper case keyword of the main statement, for example SELECT or INSERT.
+//
+// Common table expressions are skipped, so that verb of "WITH t AS (...) DELETE ..." is DELETE.
+func sqlVerb(tokens []sqlToken) string {
+	tokens = significant(tokens)
+
+	i := 0
+	for i < len(tokens) && tokens[i].is("(") {
+		i++
+	}
+
+	if i == len(tokens) {
+		return ""
+	}
+
+	verb := tokens[i].keyword()
+	if verb != "WITH" {
+		return verb
+	}
+
+	depth := 0
+
+	for _, t := range tokens[i+1:] {
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			depth--
+		case depth == 0:
+			switch k := t.keyword(); k {
+			case "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE", "VALUES", "TABLE":
+				return k
+			}
+		}
+	}
+
+	return verb
+}
+
+// sqlTables returns lower case unqualified names of tables referenced by statement.
+//
+// Result is approximate and may include names of common table expressions.
+func sqlTables(tokens []sqlToken) []string {
+	var (
+		tables []string
+		seen   = map[string]bool{}
+	)
+
+	tokens = significant(tokens)
+
+	for _, i := range sqlTableRefs(tokens) {
+		name := tokens[i].text
+
+		// Qualified name, last part is a table.
+		for i+2 < len(tokens) && tokens[i+1].is(".") &&
+			(tokens[i+2].kind == sqlWord || tokens[i+2].kind == sqlIdent) {
+			i += 2
+			name = tokens[i].text
+		}
+
+		name = strings.ToLower(strings.Trim(name, "\"`"))
+
+		if !seen[name] {
+			seen[name] = true
+
+			tables = append(tables, name)
+		}
+	}
+
+	return tables
+}
+
+// sqlTableRefs returns indexes of tokens that start table references, tokens must not have comments.
+func sqlTableRefs(tokens []sqlToken) []int {
+	var (
+		refs   []int
+		expect = false
+		inFrom = false
+		prev   sqlToken
+
+		verb = sqlVerb(tokens)
+
+		// index tells that ON of CREATE INDEX starts a table reference.
+		index = false
+
+		// query tells if parentheses enclose a query, and not a function call or an expression,
+		// FROM of EXTRACT, TRIM or SUBSTRING is not a table list.
+		query = []bool{true}
+	)
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		k := t.keyword()
+
+		switch {
+		case t.is("("):
+			query = append(query, i+1 < len(tokens) && sqlQueryVerbs[tokens[i+1].keyword()])
+		case t.is(")") && len(query) > 1:
+			query = query[:len(query)-1]
+		}
+
+		switch {
+		case expect && (t.kind == sqlWord || t.kind == sqlIdent) && !sqlTableModifiers[k]:
+			expect = false
+
+			if sqlClauses[k] {
+				inFrom = false
+
+				break
+			}
+
+			refs = append(refs, i)
+
+			// Qualified name.
+			for i+2 < len(tokens) && tokens[i+1].is(".") &&
+				(tokens[i+2].kind == sqlWord || tokens[i+2].kind == sqlIdent) {
+				i += 2
+			}
+		case expect && sqlTableModifiers[k]:
+		case (k == "FROM" && query[len(query)-1] && prev.keyword() != "DISTINCT") || k == "JOIN":
+			expect, inFrom = true, true
+		case k == "INTO" || k == "TABLE" || k == "TRUNCATE":
+			expect, inFrom = true, false
+		case k == "UPDATE" && (prev.text == "" || prev.is(")") || prev.is(";")):
+			expect, inFrom = true, false
+		case inFrom && t.is(","):
+			expect = true
+		case k == "USING" && verb == "MERGE":
+			// Source table of MERGE.
+			expect, inFrom = true, false
+		case k == "USING" && verb == "DELETE" && len(query) == 1:
+			// Table list of DELETE FROM t USING a, b.
+			expect, inFrom = true, true
+		case k == "INDEX" && verb == "CREATE" && len(query) == 1:
+			index = true
+		case k == "ON" && index:
+			expect, inFrom, index = true, false, false
+		case k == "REFERENCES":
+			expect, inFrom = true, false
+		case sqlClauses[k] || t.is("(") || t.is(")") || t.is(";"):
+			expect, inFrom = false, false
+		default:
+			expect = false
+		}
+
+		prev = tokens[i]
+	}
+
+	return refs
+}
+
+// sqlOffsets returns positions of tokens in statement.
+func sqlOffsets(statement string, tokens []sqlToken) []int {
+	var (
+		offsets = make([]int, 0, len(tokens))
+		pos     int
+	)
+
+	for _, t := range tokens {
+		// Tokens are only separated by whitespace.
+		for !strings.HasPrefix(statement[pos:], t.text) {
+			pos++
+		}
+
+		offsets = append(offsets, pos)
+		pos += len(t.text)
+	}
+
+	return offsets
+}
+
+// sqlTableModifiers may precede table name.
+var sqlTableModifiers = map[string]bool{
+	"ONLY": true, "LATERAL": true, "IF": true, "NOT": true, "EXISTS": true, "TABLE": true, "IGNORE": true,
+}
+
+// sqlClauses end a list of tables.
+var sqlClauses = map[string]bool{
+	"WHERE": true, "GROUP": true, "ORDER": true, "LIMIT": true, "HAVING": true, "ON": true, "USING": true,
+	"SET": true, "UNION": true, "WINDOW": true, "FOR": true, "RETURNING": true, "OFFSET": true, "FETCH": true,
+	"INTERSECT": true, "EXCEPT": true, "VALUES": true, "SELECT": true, "DEFAULT": true,
+}
+
+// sqlQueryVerbs start a subquery in parentheses.
+var sqlQueryVerbs = map[string]bool{
+	"SELECT": true, "WITH": true, "VALUES": true, "TABLE": true,
+}
+
+// Fingerprint returns normalized statement without literals and comments.
+//
+// Literals and placeholders are replaced with "?", lists of them are collapsed to a single "?",
+// unquoted words are upper cased and whitespace is normalized, so that statements that differ
+// only in values have the same fingerprint.
+func Fingerprint(statement string) string {
+	var (
+		out  []sqlToken
+		prev sqlToken
+		b    strings.Builder
+	)
+
+	for _, t := range significant(lexSQL(statement)) {
+		switch t.kind {
+		case sqlString, sqlNumber, sqlPlaceholder:
+			// Collapse list of values.
+			if n := len(out); n >= 2 && out[n-1].is(",") && out[n-2].kind == sqlPlaceholder {
+				out = out[:n-1]
+
+				continue
+			}
+
+			t = sqlToken{kind: sqlPlaceholder, text: "?"}
+		case sqlWord:
+			t.text = strings.ToUpper(t.text)
+		}
+
+		out = append(out, t)
+	}
+
+	for i, t := range out {
+		if i > 0 && !t.is(",") && !t.is(")") && !t.is(".") && !prev.is("(") && !prev.is(".") {
+			b.WriteByte(' ')
+		}
+
+		b.WriteString(t.text)
+		prev = t
+	}
+
+	res := strings.TrimRight(b.String(), " ;")
+
+	// Collapse list of tuples.
+	for strings.Contains(res, "(?), (?)") {
+		res = strings.Replace(res, "(?), (?)", "(?)", -1)
+	}
+
+	return res
+}
@@ -0,0 +1,119 @@
+package dbwrap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLexSQL(t *testing.T) {
+	tokens := lexSQL("SELECT `a`, 'it''s' -- comment\n FROM t WHERE b = $1 AND c = ? AND d::text = :e AND f >= 1.5e-3 /* x */ AND g = $$a'b$$")
+
+	var kinds []sqlTokenKind
+
+	texts := make([]string, 0, len(tokens))
+
+	for _, tok := range tokens {
+		kinds = append(kinds, tok.kind)
+		texts = append(texts, tok.text)
+	}
+
+	assert.Equal(t, []string{
+		"SELECT", "`a`", ",", "'it''s'", "-- comment", "FROM", "t", "WHERE", "b", "=", "$1",
+		"AND", "c", "=", "?", "AND", "d", "::", "text", "=", ":e", "AND", "f", ">=", "1.5e-3",
+		"/* x */", "AND", "g", "=", "$$a'b$$",
+	}, texts)
+	assert.Equal(t, sqlComment, kinds[4])
+	assert.Equal(t, sqlPlaceholder, kinds[10])
+	assert.Equal(t, sqlNumber, kinds[24])
+	assert.Equal(t, sqlString, kinds[29])
+}
+
+func TestLexSQL_backslash(t *testing.T) {
+	for statement, texts := range map[string][]string{
+		`SELECT 'C:\', ?`:              {"SELECT", `'C:\'`, ",", "?"},
+		`SELECT E'it\'s', ?`:           {"SELECT", `E'it\'s'`, ",", "?"},
+		`SELECT 'it\'s', ?`:            {"SELECT", `'it\'s'`, ",", "?"},
+		`SELECT 'a\', 'b' WHERE c = ?`: {"SELECT", `'a\'`, ",", "'b'", "WHERE", "c", "=", "?"},
+	} {
+		tokens := lexSQL(statement)
+		res := make([]string, 0, len(tokens))
+
+		for _, tok := range tokens {
+			res = append(res, tok.text)
+		}
+
+		assert.Equal(t, texts, res, statement)
+	}
+}
+
+func TestSQLVerb(t *testing.T) {
+	for statement, verb := range map[string]string{
+		"select 1":                         "SELECT",
+		"(SELECT 1) UNION (SELECT 2)":      "SELECT",
+		"/* c */ INSERT INTO t VALUES (1)": "INSERT",
+		"WITH a AS (SELECT 1), b (x) AS (SELECT 2) DELETE FROM t": "DELETE",
+		"WITH RECURSIVE a AS (SELECT 1) SELECT * FROM a":          "SELECT",
+		"": "",
+	} {
+		assert.Equal(t, verb, sqlVerb(lexSQL(statement)), statement)
+	}
+}
+
+func TestSQLTables(t *testing.T) {
+	for statement, tables := range map[string][]string{
+		"SELECT a FROM t1 x, public.t2 AS y JOIN `T3` ON x.a = y.b WHERE c IN (SELECT d FROM t4)": {"t1", "t2", "t3", "t4"},
+		"INSERT IGNORE INTO t (a, b) VALUES (1, 2) ON DUPLICATE KEY UPDATE a = 1":                 {"t"},
+		"UPDATE ONLY \"t\" SET a = 1":              {"t"},
+		"DELETE FROM t WHERE a = 1":                {"t"},
+		"SELECT * FROM t FOR UPDATE NOWAIT":        {"t"},
+		"TRUNCATE TABLE t":                         {"t"},
+		"WITH c AS (SELECT 1) UPDATE t SET a = 1":  {"t"},
+		"MERGE INTO t USING s ON t.a = s.a":        {"t", "s"},
+		"DELETE FROM t USING u, v WHERE t.a = u.a": {"t", "u", "v"},
+		"SELECT 1": nil,
+	} {
+		assert.Equal(t, tables, sqlTables(lexSQL(statement)), statement)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	for statement, fingerprint := range map[string]string{
+		"select a, b from t where c = 'x' and d in (1, 2, 3) -- comment": "SELECT A, B FROM T WHERE C = ? AND D IN (?)",
+		"SELECT a FROM t WHERE c = $1 AND d IN ($2, $3);":                "SELECT A FROM T WHERE C = ? AND D IN (?)",
+		"INSERT INTO `t` (a, b) VALUES (?, ?), (?, ?), (?, ?)":           "INSERT INTO `t` (A, B) VALUES (?)",
+		"SELECT s.a FROM s.t": "SELECT S.A FROM S.T",
+	} {
+		assert.Equal(t, fingerprint, Fingerprint(statement), statement)
+	}
+}
+
+func TestIsReadStatement(t *testing.T) {
+	for statement, read := range map[string]bool{
+		"SELECT a FROM t":                                         true,
+		"  (select 1) union (select 2)":                           true,
+		"WITH a AS (SELECT 1) SELECT * FROM a":                    true,
+		"WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d":   false,
+		"WITH a AS (SELECT 1) UPDATE t SET b = 1":                 false,
+		"SELECT a FROM t FOR UPDATE":                              false,
+		"SELECT a FROM t FOR NO KEY UPDATE":                       false,
+		"SELECT a FROM t LOCK IN SHARE MODE":                      false,
+		"SELECT a FROM t FOR UPDATE OF t, s.u SKIP LOCKED":        false,
+		"SELECT a FROM t FOR UPDATE NOWAIT FOR SHARE OF u":        false,
+		"SELECT lock FROM t":                                      true,
+		"SELECT a FROM t WHERE lock = 1 LOCK IN SHARE MODE":       false,
+		"SELECT a FROM lock":                                      true,
+		"SELECT a INTO t2 FROM t":                                 false,
+		"SELECT a FROM t WHERE b IN (SELECT c FROM t2 FOR SHARE)": false,
+		"SELECT 'FOR UPDATE' -- INSERT":                           true,
+		"SELECT 1; DROP TABLE t":                                  false,
+		"SELECT 1;":                                               true,
+		"EXPLAIN SELECT 1":                                        true,
+		"EXPLAIN ANALYZE DELETE FROM t":                           false,
+		"SHOW TABLES":                                             true,
+		"INSERT INTO t VALUES (1)":                                false,
+		"SET search_path = public":                                false,
+	} {
+		assert.Equal(t, read, isReadStatement(lexSQL(statement)), statement)
+	}
+}
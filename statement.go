@@ -0,0 +1,146 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+)
+
+// Statement describes a parsed SQL statement.
+type Statement struct {
+	// Kind is an upper case verb of the main statement, for example SELECT or INSERT, empty if unknown.
+	Kind string
+
+	// Tables are lower case unqualified names of referenced tables, the list is approximate.
+	Tables []string
+
+	// ReadOnly is true if statement only reads data, see WithReadOnly.
+	ReadOnly bool
+
+	// Placeholders is a number of distinct argument placeholders.
+	Placeholders int
+}
+
+type statementCtxKey struct{}
+
+// sqlStatement is a statement text that is parsed on demand once.
+type sqlStatement struct {
+	text string
+
+	lexOnce   sync.Once
+	tokens    []sqlToken
+	parseOnce sync.Once
+	info      Statement
+}
+
+// opState is a state of operation that is shared by interceptor, middlewares and hooks.
+type opState struct {
+	*sqlStatement
+
+	operation Operation
+	conn      *wConn
+
+	callerSkip []string
+	callerMu   sync.Mutex
+	callerDone bool
+	caller     string
+
+	// result is set after Exec and StmtExec driver calls.
+	result driver.Result
+}
+
+// StatementInfo returns parsed statement of current operation.
+//
+// It is available in middlewares, their finalizers and rows of operations with statement,
+// parsing happens on the first call and is shared by all consumers of operation.
+func StatementInfo(ctx context.Context) (Statement, bool) {
+	p, ok := ctx.Value(statementCtxKey{}).(*opState)
+	if !ok || p.text == "" {
+		return Statement{}, false
+	}
+
+	return p.statement(), true
+}
+
+// withOperation adds state of operation to context.
+//
+// Operations without statement, like RowsClose, inherit statement of parent operation.
+// Caller is kept when interceptor rewrites statement.
+func (o Options) withOperation(ctx context.Context, operation Operation, statement string) context.Context {
+	p, ok := ctx.Value(statementCtxKey{}).(*opState)
+	if ok && p.operation == operation && p.conn == o.conn && p.text == statement {
+		return ctx
+	}
+
+	op := &opState{operation: operation, conn: o.conn, callerSkip: o.callerSkip}
+
+	switch {
+	case ok && (p.text == statement || statement == ""):
+		op.sqlStatement = p.sqlStatement
+	default:
+		op.sqlStatement = &sqlStatement{text: statement}
+	}
+
+	if ok && p.operation == operation && p.conn == o.conn {
+		p.callerMu.Lock()
+		op.caller, op.callerDone = p.caller, p.callerDone
+		p.callerMu.Unlock()
+	}
+
+	return context.WithValue(ctx, statementCtxKey{}, op)
+}
+
+// parseStatement returns parsed statement from context or a new one if context has another statement.
+func parseStatement(ctx context.Context, statement string) *sqlStatement {
+	if p, ok := ctx.Value(statementCtxKey{}).(*opState); ok && p.text == statement {
+		return p.sqlStatement
+	}
+
+	return &sqlStatement{text: statement}
+}
+
+// lex returns tokens of statement.
+func (p *sqlStatement) lex() []sqlToken {
+	p.lexOnce.Do(func() {
+		p.tokens = lexSQL(p.text)
+	})
+
+	return p.tokens
+}
+
+func (p *sqlStatement) statement() Statement {
+	p.parseOnce.Do(func() {
+		tokens := p.lex()
+		seen := map[string]bool{}
+
+		for _, t := range tokens {
+			if t.kind != sqlPlaceholder || (t.text != "?" && seen[t.text]) {
+				continue
+			}
+
+			seen[t.text] = true
+			p.info.Placeholders++
+		}
+
+		p.info.Kind = sqlVerb(tokens)
+		p.info.Tables = sqlTables(tokens)
+		p.info.ReadOnly = isReadStatement(tokens)
+	})
+
+	return p.info
+}
+
+// callerName returns caller of operation, it is resolved on the first call.
+//
+// Mutex is used instead of sync.Once to keep stack depth of Caller.
+func (p *opState) callerName() string {
+	p.callerMu.Lock()
+	defer p.callerMu.Unlock()
+
+	if !p.callerDone {
+		p.caller = Caller(p.callerSkip...)
+		p.callerDone = true
+	}
+
+	return p.caller
+}
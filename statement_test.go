@@ -0,0 +1,72 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementInfo(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		f    = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		seen = map[dbwrap.Operation]dbwrap.Statement{}
+	)
+
+	_, ok := dbwrap.StatementInfo(ctx)
+	assert.False(t, ok)
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(),
+		dbwrap.WithAllOperations(),
+		dbwrap.WithMiddleware(func(
+			ctx context.Context,
+			operation dbwrap.Operation,
+			statement string,
+			args []driver.NamedValue,
+		) (context.Context, func(error)) {
+			return ctx, func(err error) {
+				if st, ok := dbwrap.StatementInfo(ctx); ok {
+					seen[operation] = st
+				}
+			}
+		}),
+	))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err := db.ExecContext(ctx, `UPDATE "Users" u SET name = $1 WHERE id = $2 OR parent = $2`, "a", 1)
+	require.NoError(t, err)
+
+	rows, err := db.QueryContext(ctx, "SELECT * FROM orders o JOIN s.items i ON i.id = o.id WHERE o.a IN (?, ?)", 1, 2)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	require.NoError(t, db.PingContext(ctx))
+
+	assert.Equal(t, dbwrap.Statement{
+		Kind:         "UPDATE",
+		Tables:       []string{"users"},
+		Placeholders: 2,
+	}, seen[dbwrap.Exec])
+
+	select1 := dbwrap.Statement{
+		Kind:         "SELECT",
+		Tables:       []string{"orders", "items"},
+		ReadOnly:     true,
+		Placeholders: 2,
+	}
+
+	assert.Equal(t, select1, seen[dbwrap.Query])
+	assert.Equal(t, select1, seen[dbwrap.RowsClose])
+
+	_, ok = seen[dbwrap.Ping]
+	assert.False(t, ok)
+}
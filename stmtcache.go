@@ -0,0 +1,250 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+)
+
+// WithStmtCache enables a cache of prepared statements per connection.
+//
+// Exec and Query that are not supported by driver directly, or are skipped by driver with
+// driver.ErrSkip, are executed with cached prepared statement instead of preparing and
+// closing statement every time. Legacy driver.Execer and driver.Queryer are tried before
+// cache, like database/sql does. Arguments are checked and converted by statement as well.
+//
+// Least recently used statements are closed when cache exceeds size, all statements are
+// closed with connection.
+//
+// Middlewares are notified with StmtCacheHit and StmtCacheMiss operations if they are enabled
+// with WithOperations or WithAllOperations.
+func WithStmtCache(size int) Option {
+	return func(o *Options) {
+		o.stmtCacheSize = size
+	}
+}
+
+// stmtCache keeps prepared statements of a connection.
+type stmtCache struct {
+	mu  sync.Mutex
+	lru *lru
+}
+
+// cachedStmt is a prepared statement that can be used concurrently with its eviction.
+type cachedStmt struct {
+	stmt    driver.Stmt
+	refs    int
+	evicted bool
+}
+
+func newStmtCache(size int) *stmtCache {
+	if size <= 0 {
+		return nil
+	}
+
+	sc := &stmtCache{}
+	sc.lru = newLRU(size, func(_ string, value interface{}) {
+		cs := value.(*cachedStmt)
+		cs.evicted = true
+
+		if cs.refs == 0 {
+			_ = cs.stmt.Close() //nolint:errcheck // Evicted statement is not used anymore.
+		}
+	})
+
+	return sc
+}
+
+// acquireStmt returns cached prepared statement and its release function.
+func (c *wConn) acquireStmt(ctx context.Context, query string) (driver.Stmt, func(), error) {
+	sc := c.stmts
+
+	sc.mu.Lock()
+	v, ok := sc.lru.get(query)
+	sc.mu.Unlock()
+
+	var (
+		cs  *cachedStmt
+		err error
+	)
+
+	if ok {
+		cs = v.(*cachedStmt)
+
+		c.stmtCacheEvent(ctx, StmtCacheHit, query, nil)
+	} else {
+		var stmt driver.Stmt
+
+		if prepCtx, ok := c.parent.(driver.ConnPrepareContext); ok {
+			stmt, err = prepCtx.PrepareContext(ctx, query)
+		} else {
+			stmt, err = c.parent.Prepare(query)
+		}
+
+		c.stmtCacheEvent(ctx, StmtCacheMiss, query, err)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cs = &cachedStmt{stmt: stmt}
+	}
+
+	var duplicate driver.Stmt
+
+	sc.mu.Lock()
+
+	if !ok {
+		// Statement could be prepared concurrently.
+		if v, found := sc.lru.get(query); found {
+			duplicate = cs.stmt
+			cs = v.(*cachedStmt)
+		} else {
+			sc.lru.set(query, cs)
+		}
+	}
+
+	cs.refs++
+
+	sc.mu.Unlock()
+
+	if duplicate != nil {
+		_ = duplicate.Close() //nolint:errcheck // Duplicate statement is not used.
+	}
+
+	return cs.stmt, func() {
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+
+		cs.refs--
+
+		if cs.evicted && cs.refs == 0 {
+			_ = cs.stmt.Close() //nolint:errcheck // Evicted statement is not used anymore.
+		}
+	}, nil
+}
+
+// stmtCacheEvent notifies middlewares.
+func (c *wConn) stmtCacheEvent(ctx context.Context, operation Operation, query string, err error) {
+	state := c.options.state()
+
+	if !state.enabled(operation) {
+		return
+	}
+
+	_, finalizers := apply(ctx, c.options, state, operation, query, nil)
+
+	for _, onFinish := range finalizers {
+		onFinish(err)
+	}
+}
+
+// execCached executes query with cached prepared statement.
+func (c *wConn) execCached(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	stmt, release, err := c.acquireStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	if args, err = convertStmtArgs(stmt, args); err != nil {
+		return nil, err
+	}
+
+	if execCtx, ok := stmt.(driver.StmtExecContext); ok {
+		return execCtx.ExecContext(ctx, args)
+	}
+
+	return stmt.Exec(values(args)) //nolint:staticcheck // Deprecated usage for backwards compatibility.
+}
+
+// queryCached executes query with cached prepared statement, statement is released with rows.
+func (c *wConn) queryCached(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	stmt, release, err := c.acquireStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if args, err = convertStmtArgs(stmt, args); err != nil {
+		release()
+
+		return nil, err
+	}
+
+	var rows driver.Rows
+
+	if queryCtx, ok := stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryCtx.QueryContext(ctx, args)
+	} else {
+		rows, err = stmt.Query(values(args)) //nolint:staticcheck // Deprecated usage for backwards compatibility.
+	}
+
+	if err != nil {
+		release()
+
+		return nil, err
+	}
+
+	released := false
+
+	return decorateRows(rows, rows.Next, func() error {
+		if !released {
+			released = true
+
+			defer release()
+		}
+
+		return rows.Close()
+	}), nil
+}
+
+// convertStmtArgs validates number of arguments and converts them with statement, as database/sql does
+// for prepared statements.
+func convertStmtArgs(stmt driver.Stmt, args []driver.NamedValue) ([]driver.NamedValue, error) {
+	if n := stmt.NumInput(); n >= 0 && n != len(args) {
+		return nil, fmt.Errorf("sql: expected %d arguments, got %d", n, len(args))
+	}
+
+	checker, isChecker := stmt.(driver.NamedValueChecker)
+	converter, isConverter := stmt.(driver.ColumnConverter) //nolint:staticcheck // Deprecated usage for backwards compatibility.
+
+	if !isChecker && !isConverter {
+		return args, nil
+	}
+
+	res := make([]driver.NamedValue, len(args))
+	copy(res, args)
+
+	for i := range res {
+		if isChecker {
+			switch err := checker.CheckNamedValue(&res[i]); {
+			case err == nil:
+				continue
+			case err != driver.ErrSkip:
+				return nil, err
+			case !isConverter:
+				// Skipped argument keeps value converted by connection.
+				continue
+			}
+		}
+
+		v, err := converter.ColumnConverter(i).ConvertValue(res[i].Value)
+		if err != nil {
+			return nil, fmt.Errorf("sql: converting argument %d type: %v", i+1, err)
+		}
+
+		res[i].Value = v
+	}
+
+	return res, nil
+}
+
+// close closes all cached statements.
+func (sc *stmtCache) close() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.lru.purge()
+}
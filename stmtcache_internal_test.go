@@ -0,0 +1,113 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type prepareConn struct {
+	driver.Conn
+	prepare func(query string) (driver.Stmt, error)
+}
+
+func (c prepareConn) Prepare(query string) (driver.Stmt, error) {
+	return c.prepare(query)
+}
+
+type closeStmt struct {
+	driver.Stmt
+	closed bool
+}
+
+func (s *closeStmt) Close() error {
+	s.closed = true
+
+	return nil
+}
+
+func TestConn_acquireStmt_concurrentMiss(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		prepared []*closeStmt
+		c        *wConn
+	)
+
+	c = newConn(prepareConn{prepare: func(query string) (driver.Stmt, error) {
+		s := &closeStmt{}
+		prepared = append(prepared, s)
+
+		// Another operation misses the cache while statement is being prepared.
+		if len(prepared) == 1 {
+			_, release, err := c.acquireStmt(ctx, query)
+			require.NoError(t, err)
+			release()
+		}
+
+		return s, nil
+	}}, Options{stmtCacheSize: 2})
+
+	stmt, release, err := c.acquireStmt(ctx, "SELECT 1")
+	require.NoError(t, err)
+	release()
+
+	require.Len(t, prepared, 2)
+	assert.True(t, prepared[0].closed)
+	assert.False(t, prepared[1].closed)
+	assert.Equal(t, prepared[1], stmt)
+
+	c.stmts.close()
+	assert.True(t, prepared[1].closed)
+}
+
+// argStmt expects a single argument and converts it to string.
+type argStmt struct {
+	driver.Stmt
+	args []driver.NamedValue
+}
+
+func (s *argStmt) NumInput() int {
+	return 1
+}
+
+func (s *argStmt) ColumnConverter(_ int) driver.ValueConverter {
+	return driver.String
+}
+
+func (s *argStmt) ExecContext(_ context.Context, args []driver.NamedValue) (driver.Result, error) {
+	s.args = args
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *argStmt) Close() error {
+	return nil
+}
+
+func TestConn_execCached_args(t *testing.T) {
+	var (
+		ctx = context.Background()
+		s   = &argStmt{}
+	)
+
+	c := newConn(prepareConn{prepare: func(query string) (driver.Stmt, error) {
+		return s, nil
+	}}, Options{stmtCacheSize: 2})
+
+	_, err := c.execCached(ctx, "DELETE FROM t WHERE a = ?", []driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: int64(2)},
+	})
+	assert.EqualError(t, err, "sql: expected 1 arguments, got 2")
+	assert.Nil(t, s.args)
+
+	_, err = c.execCached(ctx, "DELETE FROM t WHERE a = ?", []driver.NamedValue{{Ordinal: 1, Value: int64(1)}})
+	require.NoError(t, err)
+	assert.Equal(t, []driver.NamedValue{{Ordinal: 1, Value: "1"}}, s.args)
+
+	_, err = c.queryCached(ctx, "DELETE FROM t WHERE a = ?", nil)
+	assert.EqualError(t, err, "sql: expected 1 arguments, got 0")
+}
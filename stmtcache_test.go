@@ -0,0 +1,70 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithStmtCache(t *testing.T) {
+	ctx := context.Background()
+	caps := dbwraptest.AllCapabilities()
+	caps.ExecerContext = false
+	caps.QueryerContext = false
+	caps.Execer = false
+	caps.Queryer = false
+
+	f := dbwraptest.NewFake(caps)
+	tr := &dbwraptest.Trace{}
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithStmtCache(2), tr.Option(),
+		dbwrap.WithOperations(dbwrap.Exec, dbwrap.Query, dbwrap.StmtCacheHit, dbwrap.StmtCacheMiss)))
+	db.SetMaxOpenConns(1)
+
+	for i := 0; i < 2; i++ {
+		_, err := db.ExecContext(ctx, "UPDATE t SET a = ?", i)
+		require.NoError(t, err)
+
+		rows, err := db.QueryContext(ctx, "SELECT a FROM t WHERE b = ?", i)
+		require.NoError(t, err)
+		require.NoError(t, rows.Close())
+	}
+
+	tr.AssertOperations(t,
+		dbwrap.Exec, dbwrap.StmtCacheMiss, dbwrap.Query, dbwrap.StmtCacheMiss,
+		dbwrap.Exec, dbwrap.StmtCacheHit, dbwrap.Query, dbwrap.StmtCacheHit,
+	)
+
+	// Least recently used statement is evicted.
+	_, err := db.ExecContext(ctx, "DELETE FROM t")
+	require.NoError(t, err)
+
+	require.NoError(t, db.Close())
+
+	assert.Equal(t, []string{
+		"Connector.Connect",
+		"Conn.PrepareContext", "Stmt.ExecContext",
+		"Conn.ResetSession", "Conn.PrepareContext", "Stmt.QueryContext", "Rows.Close",
+		"Conn.ResetSession", "Stmt.ExecContext",
+		"Conn.ResetSession", "Stmt.QueryContext", "Rows.Close",
+		"Conn.ResetSession", "Conn.PrepareContext", "Stmt.Close", "Stmt.ExecContext",
+		"Stmt.Close", "Stmt.Close", "Conn.Close",
+	}, f.Methods())
+
+	// Cache operations are not enabled by default.
+	tr = &dbwraptest.Trace{}
+	db = sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithStmtCache(2), tr.Option()))
+
+	for i := 0; i < 2; i++ {
+		_, err := db.ExecContext(ctx, "UPDATE t SET a = ?", i)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, db.Close())
+	tr.AssertOperations(t, dbwrap.Exec, dbwrap.Exec)
+}
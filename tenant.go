@@ -0,0 +1,326 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+)
+
+// TenantMode is a way of routing operations to schema of tenant.
+type TenantMode int
+
+// These constants enumerate tenant routing modes.
+const (
+	// TenantSearchPath issues SET search_path when connection changes tenant (PostgreSQL).
+	TenantSearchPath = TenantMode(iota)
+
+	// TenantUse issues USE when connection changes tenant (MySQL).
+	TenantUse
+
+	// TenantQualify rewrites unqualified table names of statements to tenant.table.
+	TenantQualify
+)
+
+// TenantConfig controls tenant routing.
+type TenantConfig struct {
+	Mode TenantMode
+
+	// Default is a schema for operations without tenant in context and for connections reused from pool,
+	// such connections are switched to Default in ResetSession.
+	// Empty Default resets search_path in TenantSearchPath mode and leaves statements as is in TenantQualify mode,
+	// Connections switched to tenant are closed instead of reuse in TenantUse mode without Default.
+	Default string
+
+	// QuoteIdent quotes schema name, default is double quotes for TenantSearchPath and TenantQualify,
+	// and backticks for TenantUse.
+	QuoteIdent func(name string) string
+}
+
+type tenantCtxKey struct{}
+
+// TenantContext adds tenant to context for WithTenantRouting.
+func TenantContext(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantCtxKey{}, tenant)
+}
+
+// Tenant returns tenant from context or empty string.
+func Tenant(ctx context.Context) string {
+	t, _ := ctx.Value(tenantCtxKey{}).(string)
+
+	return t
+}
+
+// WithTenantRouting routes operations to schema of tenant from context, see TenantContext.
+//
+// In TenantSearchPath and TenantUse modes, current tenant is tracked per connection, so that switching statement
+// is only issued when tenant changes, connections are switched to Default before reuse from pool.
+// In TenantSearchPath mode, tenant switched in transaction becomes current after commit, as rollback
+// also reverts search_path.
+// Prepared statements keep schema of the moment of preparation.
+func WithTenantRouting(config TenantConfig) Option {
+	if config.QuoteIdent == nil {
+		quote := `"`
+		if config.Mode == TenantUse {
+			quote = "`"
+		}
+
+		config.QuoteIdent = func(name string) string {
+			return quote + strings.Replace(name, quote, quote+quote, -1) + quote
+		}
+	}
+
+	return func(o *Options) {
+		o.tenants = &config
+	}
+}
+
+// tenantUnknown is a tenant of connection after failed switch.
+const tenantUnknown = "\x00"
+
+// tenant returns tenant of operation.
+func (tc *TenantConfig) tenant(ctx context.Context) string {
+	if t := Tenant(ctx); t != "" {
+		return t
+	}
+
+	return tc.Default
+}
+
+// intercept qualifies table names of statement with tenant schema.
+func (tc *TenantConfig) intercept(
+	ctx context.Context,
+	operation Operation,
+	statement string,
+	args []driver.NamedValue,
+) (context.Context, string, []driver.NamedValue) {
+	switch operation {
+	case Exec, Query, Prepare:
+		if t := tc.tenant(ctx); t != "" {
+			statement = qualifyTables(statement, tc.QuoteIdent(t))
+		}
+	}
+
+	return ctx, statement, args
+}
+
+// switchStatement returns statement that changes schema of connection.
+func (tc *TenantConfig) switchStatement(tenant string) string {
+	switch {
+	case tc.Mode == TenantUse:
+		return "USE " + tc.QuoteIdent(tenant)
+	case tenant == "":
+		return "RESET search_path"
+	default:
+		return "SET search_path TO " + tc.QuoteIdent(tenant)
+	}
+}
+
+// routeTenant switches connection to tenant of operation if it differs from current one.
+func (c *wConn) routeTenant(ctx context.Context) error {
+	tc := c.options.tenants
+	if tc == nil || tc.Mode == TenantQualify {
+		return nil
+	}
+
+	return c.switchTenant(ctx, tc.tenant(ctx))
+}
+
+func (c *wConn) switchTenant(ctx context.Context, tenant string) error {
+	tc := c.options.tenants
+
+	current := c.tenant
+	if c.txTenantSet {
+		current = c.txTenant
+	}
+
+	if tenant == current || (tenant == "" && tc.Mode == TenantUse) {
+		return nil
+	}
+
+	if err := c.execParent(ctx, tc.switchStatement(tenant)); err != nil {
+		c.tenant = tenantUnknown
+		c.txTenantSet = false
+		c.options.observe(err)
+
+		return err
+	}
+
+	// SET search_path is transactional, unlike USE.
+	if c.tx != nil && tc.Mode == TenantSearchPath {
+		c.txTenant = tenant
+		c.txTenantSet = true
+
+		return nil
+	}
+
+	c.tenant = tenant
+
+	return nil
+}
+
+// endTxTenant makes tenant switched in transaction current after commit and discards it after rollback.
+func (c *wConn) endTxTenant(commit bool, err error) {
+	if !c.txTenantSet {
+		return
+	}
+
+	c.txTenantSet = false
+
+	switch {
+	case err != nil:
+		c.tenant = tenantUnknown
+	case commit:
+		c.tenant = c.txTenant
+	}
+}
+
+// resetTenant switches connection to default tenant before reuse.
+func (c *wConn) resetTenant(ctx context.Context) error {
+	tc := c.options.tenants
+	if tc == nil || tc.Mode == TenantQualify {
+		return nil
+	}
+
+	// USE can not switch back to no database, connection of another tenant must not be reused.
+	if tc.Default == "" && tc.Mode == TenantUse {
+		return driver.ErrBadConn
+	}
+
+	if err := c.switchTenant(ctx, tc.Default); err != nil {
+		return driver.ErrBadConn
+	}
+
+	return nil
+}
+
+// execParent executes statement without arguments on parent connection.
+func (c *wConn) execParent(ctx context.Context, statement string) error {
+	if e, ok := c.parent.(driver.ExecerContext); ok {
+		_, err := e.ExecContext(ctx, statement, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+
+	if p, ok := c.parent.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, statement)
+	} else {
+		stmt, err = c.parent.Prepare(statement)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close() //nolint:errcheck // Result of exec is more important.
+
+	if e, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = e.ExecContext(ctx, nil)
+	} else {
+		_, err = stmt.Exec(nil) //nolint:staticcheck // Deprecated usage for backwards compatibility.
+	}
+
+	return err
+}
+
+// qualifyTables prefixes unqualified table names with schema, names of common table expressions
+// and table functions are left as is.
+func qualifyTables(statement string, schema string) string {
+	var (
+		all      = lexSQL(statement)
+		offsets  = sqlOffsets(statement, all)
+		tokens   = make([]sqlToken, 0, len(all))
+		position = make([]int, 0, len(all))
+	)
+
+	for i, t := range all {
+		if t.kind != sqlComment {
+			tokens = append(tokens, t)
+			position = append(position, offsets[i])
+		}
+	}
+
+	ctes := sqlCTENames(tokens)
+
+	var (
+		b   strings.Builder
+		pos int
+	)
+
+	for _, i := range sqlTableRefs(tokens) {
+		t := tokens[i]
+
+		// Qualified name or table function.
+		if i+1 < len(tokens) && (tokens[i+1].is(".") || (tokens[i+1].is("(") && !tableTarget(tokens, i))) {
+			continue
+		}
+
+		if ctes[strings.ToLower(strings.Trim(t.text, "\"`"))] {
+			continue
+		}
+
+		b.WriteString(statement[pos:position[i]])
+		b.WriteString(schema)
+		b.WriteByte('.')
+
+		pos = position[i]
+	}
+
+	if pos == 0 {
+		return statement
+	}
+
+	b.WriteString(statement[pos:])
+
+	return b.String()
+}
+
+// tableTarget checks if table reference is a target of INSERT or DDL, so that parentheses after
+// its name enclose columns instead of arguments of table function.
+func tableTarget(tokens []sqlToken, i int) bool {
+	j := i - 1
+
+	for j > 0 && sqlTableModifiers[tokens[j].keyword()] && tokens[j].keyword() != "TABLE" {
+		j--
+	}
+
+	switch tokens[j].keyword() {
+	case "INTO", "TABLE", "ON", "REFERENCES":
+		return true
+	}
+
+	return false
+}
+
+// sqlCTENames returns lower case names of common table expressions, tokens must not have comments.
+func sqlCTENames(tokens []sqlToken) map[string]bool {
+	names := map[string]bool{}
+
+	for i, t := range tokens {
+		if t.keyword() != "AS" || i+1 >= len(tokens) || !tokens[i+1].is("(") || i == 0 {
+			continue
+		}
+
+		j := i - 1
+
+		// Column list of common table expression.
+		if tokens[j].is(")") {
+			for j > 0 && !tokens[j].is("(") {
+				j--
+			}
+
+			j--
+		}
+
+		if j >= 0 && (tokens[j].kind == sqlWord || tokens[j].kind == sqlIdent) {
+			names[strings.ToLower(strings.Trim(tokens[j].text, "\"`"))] = true
+		}
+	}
+
+	return names
+}
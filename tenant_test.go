@@ -0,0 +1,253 @@
+package dbwrap_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/bool64/dbwrap"
+	"github.com/bool64/dbwrap/dbwraptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func statements(f *dbwraptest.Fake) []string {
+	var res []string
+
+	for _, c := range f.Calls() {
+		if c.Method == "Conn.ExecContext" || c.Method == "Conn.QueryContext" || c.Method == "Conn.ResetSession" {
+			res = append(res, c.Method+" "+c.Statement)
+		}
+	}
+
+	return res
+}
+
+func TestWithTenantRouting_searchPath(t *testing.T) {
+	var (
+		ctx = context.Background()
+		a   = dbwrap.TenantContext(ctx, "a")
+		b   = dbwrap.TenantContext(ctx, `b"c`)
+		f   = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	)
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithTenantRouting(dbwrap.TenantConfig{})))
+	db.SetMaxOpenConns(1)
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	assert.Equal(t, "a", dbwrap.Tenant(a))
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	_, err = conn.ExecContext(a, "DELETE FROM t")
+	require.NoError(t, err)
+
+	_, err = conn.ExecContext(a, "DELETE FROM t")
+	require.NoError(t, err)
+
+	rows, err := conn.QueryContext(b, "SELECT * FROM t")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+	require.NoError(t, conn.Close())
+
+	_, err = db.ExecContext(ctx, "DELETE FROM t")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`Conn.ExecContext SET search_path TO "a"`,
+		"Conn.ExecContext DELETE FROM t",
+		"Conn.ExecContext DELETE FROM t",
+		`Conn.ExecContext SET search_path TO "b""c"`,
+		"Conn.QueryContext SELECT * FROM t",
+		"Conn.ResetSession ",
+		"Conn.ExecContext RESET search_path",
+		"Conn.ExecContext DELETE FROM t",
+	}, statements(f))
+}
+
+func TestWithTenantRouting_tx(t *testing.T) {
+	var (
+		ctx = context.Background()
+		a   = dbwrap.TenantContext(ctx, "a")
+		b   = dbwrap.TenantContext(ctx, "b")
+		f   = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	)
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(), dbwrap.WithTenantRouting(dbwrap.TenantConfig{})))
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+
+	// Rollback reverts search_path.
+	tx, err := conn.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(a, "DELETE FROM t")
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	_, err = conn.ExecContext(a, "DELETE FROM t")
+	require.NoError(t, err)
+
+	// Commit keeps search_path.
+	tx, err = conn.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tx.ExecContext(b, "DELETE FROM t")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	_, err = conn.ExecContext(b, "DELETE FROM t")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`Conn.ExecContext SET search_path TO "a"`,
+		"Conn.ExecContext DELETE FROM t",
+		`Conn.ExecContext SET search_path TO "a"`,
+		"Conn.ExecContext DELETE FROM t",
+		"Conn.ExecContext RESET search_path",
+		`Conn.ExecContext SET search_path TO "b"`,
+		"Conn.ExecContext DELETE FROM t",
+		"Conn.ExecContext DELETE FROM t",
+	}, statements(f))
+}
+
+func TestWithTenantRouting_use(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		f      = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		failed = false
+	)
+
+	f.Exec = func(ctx context.Context, statement string, _ []driver.NamedValue) (driver.Result, error) {
+		if statement == "USE `b`" && !failed {
+			failed = true
+
+			return nil, errors.New("failed")
+		}
+
+		return driver.RowsAffected(0), nil
+	}
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(),
+		dbwrap.WithTenantRouting(dbwrap.TenantConfig{Mode: dbwrap.TenantUse, Default: "main"})))
+	db.SetMaxOpenConns(1)
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err := db.ExecContext(ctx, "DELETE FROM t")
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(dbwrap.TenantContext(ctx, "b"), "DELETE FROM t")
+	assert.EqualError(t, err, "failed")
+
+	_, err = db.ExecContext(dbwrap.TenantContext(ctx, "b"), "DELETE FROM t")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"Conn.ExecContext USE `main`",
+		"Conn.ExecContext DELETE FROM t",
+		"Conn.ResetSession ",
+		"Conn.ExecContext USE `b`",
+		"Conn.ResetSession ",
+		"Conn.ExecContext USE `main`",
+		"Conn.ExecContext USE `b`",
+		"Conn.ExecContext DELETE FROM t",
+	}, statements(f))
+}
+
+func TestWithTenantRouting_useWithoutDefault(t *testing.T) {
+	var (
+		ctx = context.Background()
+		f   = dbwraptest.NewFake(dbwraptest.AllCapabilities())
+	)
+
+	db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(),
+		dbwrap.WithTenantRouting(dbwrap.TenantConfig{Mode: dbwrap.TenantUse})))
+	db.SetMaxOpenConns(1)
+
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	_, err := db.ExecContext(dbwrap.TenantContext(ctx, "a"), "DELETE FROM t")
+	require.NoError(t, err)
+
+	// Connection of tenant a is not reused without tenant.
+	_, err = db.ExecContext(ctx, "DELETE FROM t")
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, "DELETE FROM t")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"Conn.ExecContext USE `a`",
+		"Conn.ExecContext DELETE FROM t",
+		"Conn.ResetSession ",
+		"Conn.ExecContext DELETE FROM t",
+		"Conn.ResetSession ",
+		"Conn.ExecContext DELETE FROM t",
+	}, statements(f))
+	assert.Contains(t, f.Methods(), "Conn.Close")
+}
+
+func TestWithTenantRouting_qualify(t *testing.T) {
+	ctx := dbwrap.TenantContext(context.Background(), "acme")
+
+	for statement, expected := range map[string]string{
+		"SELECT * FROM users u JOIN public.roles r ON r.id = u.role_id WHERE u.id IN (SELECT id FROM bans)": `SELECT * FROM "acme".users u JOIN public.roles r ON r.id = u.role_id WHERE u.id IN (SELECT id FROM "acme".bans)`,
+		"INSERT INTO users (id, name) VALUES (?, ?)":                                                        `INSERT INTO "acme".users (id, name) VALUES (?, ?)`,
+		"UPDATE `users` SET name = 'FROM x' -- FROM y":                                                      "UPDATE \"acme\".`users` SET name = 'FROM x' -- FROM y",
+		"WITH recent AS (SELECT * FROM orders) SELECT * FROM recent, items":                                 `WITH recent AS (SELECT * FROM "acme".orders) SELECT * FROM recent, "acme".items`,
+		"SELECT * FROM generate_series(1, 10)":                                                              "SELECT * FROM generate_series(1, 10)",
+		"SELECT 1":                                                                                          "SELECT 1",
+		"DELETE FROM sessions /* comment */ WHERE id = ?":                                                   `DELETE FROM "acme".sessions /* comment */ WHERE id = ?`,
+		"SELECT EXTRACT(YEAR FROM created_at) FROM orders":                                                  `SELECT EXTRACT(YEAR FROM created_at) FROM "acme".orders`,
+		"SELECT TRIM(BOTH ' ' FROM name), SUBSTRING(code FROM 2) FROM users":                                `SELECT TRIM(BOTH ' ' FROM name), SUBSTRING(code FROM 2) FROM "acme".users`,
+		"SELECT * FROM users WHERE a IS DISTINCT FROM b AND c IS NOT DISTINCT FROM d":                       `SELECT * FROM "acme".users WHERE a IS DISTINCT FROM b AND c IS NOT DISTINCT FROM d`,
+		"SELECT * FROM users WHERE EXISTS (SELECT 1 FROM bans WHERE LOWER(TRIM(FROM name)) = ?)":            `SELECT * FROM "acme".users WHERE EXISTS (SELECT 1 FROM "acme".bans WHERE LOWER(TRIM(FROM name)) = ?)`,
+		"MERGE INTO users u USING staged s ON u.id = s.id WHEN MATCHED THEN UPDATE SET name = s.name":       `MERGE INTO "acme".users u USING "acme".staged s ON u.id = s.id WHEN MATCHED THEN UPDATE SET name = s.name`,
+		"MERGE INTO users USING (SELECT * FROM staged JOIN roles USING (id)) s ON users.id = s.id":          `MERGE INTO "acme".users USING (SELECT * FROM "acme".staged JOIN "acme".roles USING (id)) s ON users.id = s.id`,
+		"SELECT * FROM users JOIN roles USING (id)":                                                         `SELECT * FROM "acme".users JOIN "acme".roles USING (id)`,
+		"DELETE FROM users USING bans, roles WHERE users.id = bans.id":                                      `DELETE FROM "acme".users USING "acme".bans, "acme".roles WHERE users.id = bans.id`,
+		"DELETE FROM users USING bans b JOIN roles USING (id) WHERE users.id = b.id":                        `DELETE FROM "acme".users USING "acme".bans b JOIN "acme".roles USING (id) WHERE users.id = b.id`,
+		"UPDATE users SET name = s.name FROM staged s, roles r WHERE users.id = s.id":                       `UPDATE "acme".users SET name = s.name FROM "acme".staged s, "acme".roles r WHERE users.id = s.id`,
+		"CREATE TABLE IF NOT EXISTS users (id int REFERENCES roles (id))":                                   `CREATE TABLE IF NOT EXISTS "acme".users (id int REFERENCES "acme".roles (id))`,
+		"CREATE UNIQUE INDEX users_name ON users (name)":                                                    `CREATE UNIQUE INDEX users_name ON "acme".users (name)`,
+		"ALTER TABLE users ADD COLUMN age int":                                                              `ALTER TABLE "acme".users ADD COLUMN age int`,
+		"DROP TABLE users":                                                                                  `DROP TABLE "acme".users`,
+	} {
+		f := dbwraptest.NewFake(dbwraptest.AllCapabilities())
+		db := sql.OpenDB(dbwrap.WrapConnector(f.Connector(),
+			dbwrap.WithTenantRouting(dbwrap.TenantConfig{Mode: dbwrap.TenantQualify})))
+
+		_, err := db.ExecContext(ctx, statement)
+		require.NoError(t, err)
+
+		_, err = db.ExecContext(context.Background(), statement)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"Conn.ExecContext " + expected,
+			"Conn.ResetSession ",
+			"Conn.ExecContext " + statement,
+		}, statements(f))
+		require.NoError(t, db.Close())
+	}
+}